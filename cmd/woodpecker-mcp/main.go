@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/sirupsen/logrus"
@@ -59,7 +62,7 @@ var serveCmd = &cobra.Command{
 	Short: "Start the MCP server",
 	Long:  `Start the MCP server to handle requests from AI agents`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runServer()
+		return runServer(cmd)
 	},
 }
 
@@ -136,6 +139,11 @@ func init() {
 		logger.WithError(err).Fatal("Failed to bind log-format flag")
 	}
 
+	// Serve flags override the server section of the config file
+	serveCmd.Flags().String("transport", config.TransportStdio, "MCP transport (stdio, http, sse)")
+	serveCmd.Flags().String("listen", ":8080", "listen address for the http and sse transports")
+	serveCmd.Flags().Bool("read-only", false, "expose only tools that do not modify Woodpecker state")
+
 	// Add subcommands
 	rootCmd.AddCommand(serveCmd)
 	rootCmd.AddCommand(configCmd)
@@ -208,7 +216,7 @@ func configureLogger() {
 	}
 }
 
-func runServer() error {
+func runServer(cmd *cobra.Command) error {
 	fmt.Println(titleStyle.Render("Starting Woodpecker MCP Server"))
 
 	// Load configuration
@@ -217,6 +225,16 @@ func runServer() error {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
+	if cmd.Flags().Changed("transport") {
+		cfg.Server.Transport, _ = cmd.Flags().GetString("transport")
+	}
+	if cmd.Flags().Changed("listen") {
+		cfg.Server.Address, _ = cmd.Flags().GetString("listen")
+	}
+	if cmd.Flags().Changed("read-only") {
+		cfg.Server.ReadOnly, _ = cmd.Flags().GetBool("read-only")
+	}
+
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
 		fmt.Println(errorStyle.Render(fmt.Sprintf("Configuration error: %v", err)))
@@ -226,8 +244,10 @@ func runServer() error {
 
 	// Create Woodpecker client
 	wclient, err := client.New(client.Config{
-		URL:   cfg.Woodpecker.URL,
-		Token: cfg.Woodpecker.Token,
+		URL:              cfg.Woodpecker.URL,
+		Token:            cfg.Woodpecker.Token,
+		RetryMaxAttempts: cfg.Woodpecker.Retry.MaxAttempts,
+		RetryMaxDelay:    cfg.Woodpecker.Retry.MaxDelay,
 	}, logger)
 	if err != nil {
 		fmt.Println(errorStyle.Render(fmt.Sprintf("Failed to connect to Woodpecker: %v", err)))
@@ -239,12 +259,20 @@ func runServer() error {
 	if err != nil {
 		return fmt.Errorf("failed to create MCP server: %w", err)
 	}
+	defer func() {
+		if err := mcpServer.Close(); err != nil {
+			logger.WithError(err).Warn("Failed to close MCP server")
+		}
+	}()
 
 	fmt.Println(successStyle.Render("MCP server started successfully"))
 	fmt.Println(infoStyle.Render(fmt.Sprintf("Connected to Woodpecker server: %s", cfg.Woodpecker.URL)))
 
-	// Start serving
-	return mcpServer.Serve()
+	// Start serving until SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return mcpServer.Serve(ctx)
 }
 
 func showConfig() error {
@@ -258,6 +286,11 @@ func showConfig() error {
 
 	fmt.Printf("Server Name: %s\n", infoStyle.Render(cfg.Server.Name))
 	fmt.Printf("Server Version: %s\n", infoStyle.Render(cfg.Server.Version))
+	fmt.Printf("Server Transport: %s\n", infoStyle.Render(cfg.Server.Transport))
+	fmt.Printf("Read-only: %s\n", infoStyle.Render(fmt.Sprintf("%t", cfg.Server.ReadOnly)))
+	if cfg.Server.Transport != config.TransportStdio {
+		fmt.Printf("Server Address: %s\n", infoStyle.Render(cfg.Server.Address))
+	}
 	fmt.Printf("Woodpecker URL: %s\n", infoStyle.Render(cfg.Woodpecker.URL))
 
 	if cfg.Woodpecker.Token != "" {
@@ -351,8 +384,10 @@ func loginAuth() error {
 
 	// Test the token
 	wclient, err := client.New(client.Config{
-		URL:   cfg.Woodpecker.URL,
-		Token: token,
+		URL:              cfg.Woodpecker.URL,
+		Token:            token,
+		RetryMaxAttempts: cfg.Woodpecker.Retry.MaxAttempts,
+		RetryMaxDelay:    cfg.Woodpecker.Retry.MaxDelay,
 	}, logger)
 	if err != nil {
 		fmt.Println(errorStyle.Render(fmt.Sprintf("Authentication failed: %v", err)))
@@ -360,7 +395,7 @@ func loginAuth() error {
 	}
 
 	// Get user info to verify token
-	user, err := wclient.GetCurrentUser()
+	user, err := wclient.GetCurrentUser(context.Background())
 	if err != nil {
 		fmt.Println(errorStyle.Render(fmt.Sprintf("Failed to verify authentication: %v", err)))
 		return err
@@ -405,8 +440,10 @@ func testConnection() error {
 
 	// Test connection
 	wclient, err := client.New(client.Config{
-		URL:   cfg.Woodpecker.URL,
-		Token: cfg.Woodpecker.Token,
+		URL:              cfg.Woodpecker.URL,
+		Token:            cfg.Woodpecker.Token,
+		RetryMaxAttempts: cfg.Woodpecker.Retry.MaxAttempts,
+		RetryMaxDelay:    cfg.Woodpecker.Retry.MaxDelay,
 	}, logger)
 	if err != nil {
 		fmt.Println(errorStyle.Render(fmt.Sprintf("Connection failed: %v", err)))
@@ -414,14 +451,14 @@ func testConnection() error {
 	}
 
 	// Get user info
-	user, err := wclient.GetCurrentUser()
+	user, err := wclient.GetCurrentUser(context.Background())
 	if err != nil {
 		fmt.Println(errorStyle.Render(fmt.Sprintf("Failed to get user info: %v", err)))
 		return err
 	}
 
 	// Get repositories count
-	repos, err := wclient.ListRepositories()
+	repos, err := wclient.ListRepositories(context.Background())
 	if err != nil {
 		fmt.Println(errorStyle.Render(fmt.Sprintf("Failed to list repositories: %v", err)))
 		return err
@@ -33,8 +33,10 @@ func testMCPTools() error {
 
 	// Create Woodpecker client
 	wclient, err := client.New(client.Config{
-		URL:   cfg.Woodpecker.URL,
-		Token: cfg.Woodpecker.Token,
+		URL:              cfg.Woodpecker.URL,
+		Token:            cfg.Woodpecker.Token,
+		RetryMaxAttempts: cfg.Woodpecker.Retry.MaxAttempts,
+		RetryMaxDelay:    cfg.Woodpecker.Retry.MaxDelay,
 	}, logger)
 	if err != nil {
 		fmt.Println(errorStyle.Render(fmt.Sprintf("Failed to connect to Woodpecker: %v", err)))
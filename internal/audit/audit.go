@@ -0,0 +1,165 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/config"
+)
+
+const (
+	OutcomeSuccess              = "success"
+	OutcomeError                = "error"
+	OutcomeConfirmationRequired = "confirmation_required"
+)
+
+// maxArgumentLength bounds how much of a single string argument is recorded
+const maxArgumentLength = 256
+
+// Record is one tool invocation, written as a single JSON line
+type Record struct {
+	Time      time.Time              `json:"time"`
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	RepoID    int64                  `json:"repo_id,omitempty"`
+	User      string                 `json:"user,omitempty"`
+	Outcome   string                 `json:"outcome"`
+	ErrorCode string                 `json:"error_code,omitempty"`
+	LatencyMS int64                  `json:"latency_ms"`
+}
+
+// sink receives each encoded record without its trailing newline
+type sink interface {
+	write(line []byte) error
+	close() error
+}
+
+// Logger writes audit records to every configured sink. Writes are serialized so
+// records from concurrent tool calls never interleave.
+type Logger struct {
+	mu    sync.Mutex
+	sinks []sink
+}
+
+// New opens the sinks enabled in cfg; it returns nil when auditing is disabled
+func New(cfg config.AuditConfig) (*Logger, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	l := &Logger{}
+	if cfg.File != "" {
+		f, err := openRotatingFile(cfg.File, int64(cfg.MaxSizeMB)*1024*1024, cfg.MaxBackups)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open audit file: %w", err)
+		}
+		l.sinks = append(l.sinks, f)
+	}
+	if cfg.Syslog.Enabled {
+		s, err := openSyslog(cfg.Syslog)
+		if err != nil {
+			_ = l.Close()
+			return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+		}
+		l.sinks = append(l.sinks, s)
+	}
+
+	return l, nil
+}
+
+// Record writes r to every sink; a nil Logger discards it
+func (l *Logger) Record(r Record) error {
+	if l == nil {
+		return nil
+	}
+
+	line, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit record: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var errs []error
+	for _, s := range l.sinks {
+		if err := s.write(line); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close flushes and closes every sink
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var errs []error
+	for _, s := range l.sinks {
+		if err := s.close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	l.sinks = nil
+	return errors.Join(errs...)
+}
+
+// SanitizeArguments returns a copy of arguments that is safe to persist: values of
+// credential-like keys are masked and long strings are truncated
+func SanitizeArguments(arguments map[string]interface{}) map[string]interface{} {
+	if len(arguments) == 0 {
+		return nil
+	}
+
+	sanitized := make(map[string]interface{}, len(arguments))
+	for k, v := range arguments {
+		sanitized[k] = sanitizeValue(k, v)
+	}
+	return sanitized
+}
+
+func sanitizeValue(key string, value interface{}) interface{} {
+	if isSensitiveKey(key) {
+		return "[REDACTED]"
+	}
+
+	switch v := value.(type) {
+	case string:
+		if len(v) > maxArgumentLength {
+			return v[:maxArgumentLength] + "...[truncated]"
+		}
+		return v
+	case map[string]interface{}:
+		return SanitizeArguments(v)
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = sanitizeValue(key, item)
+		}
+		return items
+	}
+	return value
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	// Secret tools carry the secret itself in "value"
+	if key == "value" {
+		return true
+	}
+	for _, marker := range []string{"token", "password", "secret", "credential"} {
+		if strings.Contains(key, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/config"
+)
+
+func readRecords(t *testing.T, path string) []Record {
+	t.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r Record
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &r))
+		records = append(records, r)
+	}
+	require.NoError(t, scanner.Err())
+	return records
+}
+
+func TestNew_DisabledReturnsNil(t *testing.T) {
+	l, err := New(config.AuditConfig{File: filepath.Join(t.TempDir(), "audit.jsonl")})
+	require.NoError(t, err)
+	require.Nil(t, l)
+
+	// A nil Logger is usable and discards records
+	require.NoError(t, l.Record(Record{Tool: "get_logs"}))
+	require.NoError(t, l.Close())
+}
+
+func TestLogger_AppendsJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "audit.jsonl")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+	require.NoError(t, os.WriteFile(path, []byte(`{"tool":"earlier","outcome":"success","time":"2024-01-01T00:00:00Z","latency_ms":1}`+"\n"), 0o600))
+
+	l, err := New(config.AuditConfig{Enabled: true, File: path})
+	require.NoError(t, err)
+
+	require.NoError(t, l.Record(Record{Time: time.Now(), Tool: "stop_pipeline", RepoID: 7, User: "alice", Outcome: OutcomeError, ErrorCode: "policy_denied"}))
+	require.NoError(t, l.Close())
+
+	records := readRecords(t, path)
+	require.Len(t, records, 2)
+	require.Equal(t, "earlier", records[0].Tool)
+	require.Equal(t, "stop_pipeline", records[1].Tool)
+	require.Equal(t, int64(7), records[1].RepoID)
+	require.Equal(t, "alice", records[1].User)
+	require.Equal(t, "policy_denied", records[1].ErrorCode)
+}
+
+func TestRotatingFile_RotatesAndPrunes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	f, err := openRotatingFile(path, 64, 2)
+	require.NoError(t, err)
+
+	tick := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	f.now = func() time.Time {
+		tick = tick.Add(time.Second)
+		return tick
+	}
+
+	line := []byte(strings.Repeat("x", 40))
+	for i := 0; i < 5; i++ {
+		require.NoError(t, f.write(line))
+	}
+	require.NoError(t, f.close())
+
+	backups, err := filepath.Glob(path + ".*")
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(line)+1), info.Size())
+}
+
+func TestSanitizeArguments(t *testing.T) {
+	long := strings.Repeat("a", maxArgumentLength+10)
+	sanitized := SanitizeArguments(map[string]interface{}{
+		"repo_id":       float64(7),
+		"confirm_token": "abc",
+		"password":      "hunter2",
+		"value":         "hunter3",
+		"path":          long,
+		"variables":     map[string]interface{}{"DEPLOY_SECRET": "s3cr3t", "ENV": "prod"},
+	})
+
+	require.Equal(t, float64(7), sanitized["repo_id"])
+	require.Equal(t, "[REDACTED]", sanitized["confirm_token"])
+	require.Equal(t, "[REDACTED]", sanitized["password"])
+	require.Equal(t, "[REDACTED]", sanitized["value"])
+	require.True(t, strings.HasSuffix(sanitized["path"].(string), "...[truncated]"))
+
+	variables := sanitized["variables"].(map[string]interface{})
+	require.Equal(t, "[REDACTED]", variables["DEPLOY_SECRET"])
+	require.Equal(t, "prod", variables["ENV"])
+
+	require.Nil(t, SanitizeArguments(nil))
+}
@@ -0,0 +1,120 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// rotatingFile appends lines to path and, once maxSize would be exceeded, renames
+// it to path.<timestamp> and starts a new file, keeping at most maxBackups of those
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	now        func() time.Time
+
+	file *os.File
+	size int64
+}
+
+func openRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+
+	f := &rotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+		now:        time.Now,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+func (f *rotatingFile) write(line []byte) error {
+	n := int64(len(line)) + 1
+	if f.maxSize > 0 && f.size > 0 && f.size+n > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return fmt.Errorf("failed to rotate audit file: %w", err)
+		}
+	}
+
+	written, err := f.file.Write(append(line, '\n'))
+	f.size += int64(written)
+	return err
+}
+
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+
+	backup := f.path + "." + f.now().UTC().Format("20060102T150405.000000000")
+	if err := os.Rename(f.path, backup); err != nil {
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	return f.prune()
+}
+
+// prune removes the oldest backups beyond maxBackups; timestamps sort lexically
+func (f *rotatingFile) prune() error {
+	if f.maxBackups <= 0 {
+		return nil
+	}
+
+	backups, err := filepath.Glob(f.path + ".*")
+	if err != nil {
+		return err
+	}
+	prefix := f.path + "."
+	kept := backups[:0]
+	for _, b := range backups {
+		if strings.HasPrefix(b, prefix) {
+			kept = append(kept, b)
+		}
+	}
+	sort.Strings(kept)
+
+	for len(kept) > f.maxBackups {
+		if err := os.Remove(kept[0]); err != nil {
+			return err
+		}
+		kept = kept[1:]
+	}
+	return nil
+}
+
+func (f *rotatingFile) close() error {
+	if err := f.file.Sync(); err != nil {
+		_ = f.file.Close()
+		return err
+	}
+	return f.file.Close()
+}
@@ -0,0 +1,29 @@
+//go:build !windows && !plan9
+
+package audit
+
+import (
+	"log/syslog"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/config"
+)
+
+type syslogSink struct {
+	writer *syslog.Writer
+}
+
+func openSyslog(cfg config.AuditSyslogConfig) (sink, error) {
+	writer, err := syslog.Dial(cfg.Network, cfg.Address, syslog.LOG_INFO|syslog.LOG_AUTH, cfg.Tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{writer: writer}, nil
+}
+
+func (s *syslogSink) write(line []byte) error {
+	return s.writer.Info(string(line))
+}
+
+func (s *syslogSink) close() error {
+	return s.writer.Close()
+}
@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package audit
+
+import (
+	"errors"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/config"
+)
+
+func openSyslog(cfg config.AuditSyslogConfig) (sink, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+// agentPageSize and maxAgentPages bound how much of the agent list ListAgents reads
+const (
+	agentPageSize = 50
+	maxAgentPages = 20
+)
+
+// Agent and queue methods. Woodpecker only serves them to admins.
+
+// ListAgents returns every agent registered with the server
+func (c *Client) ListAgents(ctx context.Context) ([]*woodpecker.Agent, error) {
+	var agents []*woodpecker.Agent
+	for page := 1; page <= maxAgentPages; page++ {
+		if err := c.waitForRateLimit(ctx); err != nil {
+			return nil, err
+		}
+		batch, err := c.api(ctx).AgentList(woodpecker.AgentListOptions{
+			ListOptions: woodpecker.ListOptions{Page: page, PerPage: agentPageSize},
+		})
+		if err != nil {
+			c.logger.WithFields(logrus.Fields{
+				"page":  page,
+				"error": err,
+			}).Error("Failed to list agents")
+			return nil, wrapError(err, "failed to list agents")
+		}
+		agents = append(agents, batch...)
+		if len(batch) < agentPageSize {
+			break
+		}
+	}
+
+	return agents, nil
+}
+
+// GetAgent returns the agent with the given ID
+func (c *Client) GetAgent(ctx context.Context, agentID int64) (*woodpecker.Agent, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	agent, err := c.api(ctx).Agent(agentID)
+	if err != nil {
+		c.logger.WithFields(logrus.Fields{
+			"agent_id": agentID,
+			"error":    err,
+		}).Error("Failed to get agent")
+		return nil, wrapError(err, "failed to get agent %d", agentID)
+	}
+
+	return agent, nil
+}
+
+// ListAgentTasks returns the workflows an agent is running
+func (c *Client) ListAgentTasks(ctx context.Context, agentID int64) ([]*woodpecker.Task, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	tasks, err := c.api(ctx).AgentTasksList(agentID)
+	if err != nil {
+		c.logger.WithFields(logrus.Fields{
+			"agent_id": agentID,
+			"error":    err,
+		}).Error("Failed to list agent tasks")
+		return nil, wrapError(err, "failed to list tasks of agent %d", agentID)
+	}
+
+	return tasks, nil
+}
+
+// GetQueueInfo returns the pending, blocked and running workflows of the server's queue
+func (c *Client) GetQueueInfo(ctx context.Context) (*woodpecker.Info, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	info, err := c.api(ctx).QueueInfo()
+	if err != nil {
+		c.logger.WithError(err).Error("Failed to get queue info")
+		return nil, wrapError(err, "failed to get queue info")
+	}
+
+	return info, nil
+}
@@ -0,0 +1,133 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+// Cron methods
+func (c *Client) ListCrons(ctx context.Context, repoID int64) ([]*woodpecker.Cron, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	crons, err := c.api(ctx).CronList(repoID, woodpecker.CronListOptions{})
+	if err != nil {
+		c.logger.WithFields(logrus.Fields{
+			"repo_id": repoID,
+			"error":   err,
+		}).Error("Failed to list crons")
+		return nil, wrapError(err, "failed to list crons for repo %d", repoID)
+	}
+
+	return crons, nil
+}
+
+func (c *Client) GetCron(ctx context.Context, repoID, cronID int64) (*woodpecker.Cron, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	cron, err := c.api(ctx).CronGet(repoID, cronID)
+	if err != nil {
+		c.logger.WithFields(logrus.Fields{
+			"repo_id": repoID,
+			"cron_id": cronID,
+			"error":   err,
+		}).Error("Failed to get cron")
+		return nil, wrapError(err, "failed to get cron %d for repo %d", cronID, repoID)
+	}
+
+	return cron, nil
+}
+
+func (c *Client) CreateCron(ctx context.Context, repoID int64, cron *woodpecker.Cron) (*woodpecker.Cron, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	created, err := c.api(ctx).CronCreate(repoID, cron)
+	if err != nil {
+		c.logger.WithFields(logrus.Fields{
+			"repo_id": repoID,
+			"cron":    cron.Name,
+			"error":   err,
+		}).Error("Failed to create cron")
+		return nil, wrapError(err, "failed to create cron %s for repo %d", cron.Name, repoID)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"repo_id": repoID,
+		"cron_id": created.ID,
+		"cron":    created.Name,
+	}).Info("Created cron")
+	return created, nil
+}
+
+// UpdateCron changes the cron with cron.ID; empty fields keep their current value
+func (c *Client) UpdateCron(ctx context.Context, repoID int64, cron *woodpecker.Cron) (*woodpecker.Cron, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	updated, err := c.api(ctx).CronUpdate(repoID, cron)
+	if err != nil {
+		c.logger.WithFields(logrus.Fields{
+			"repo_id": repoID,
+			"cron_id": cron.ID,
+			"error":   err,
+		}).Error("Failed to update cron")
+		return nil, wrapError(err, "failed to update cron %d for repo %d", cron.ID, repoID)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"repo_id": repoID,
+		"cron_id": cron.ID,
+	}).Info("Updated cron")
+	return updated, nil
+}
+
+func (c *Client) DeleteCron(ctx context.Context, repoID, cronID int64) error {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return err
+	}
+	if err := c.api(ctx).CronDelete(repoID, cronID); err != nil {
+		c.logger.WithFields(logrus.Fields{
+			"repo_id": repoID,
+			"cron_id": cronID,
+			"error":   err,
+		}).Error("Failed to delete cron")
+		return wrapError(err, "failed to delete cron %d for repo %d", cronID, repoID)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"repo_id": repoID,
+		"cron_id": cronID,
+	}).Info("Deleted cron")
+	return nil
+}
+
+// RunCron starts a pipeline for the cron right away, outside its schedule.
+// woodpecker-go does not wrap this endpoint.
+func (c *Client) RunCron(ctx context.Context, repoID, cronID int64) (*woodpecker.Pipeline, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	var pipeline woodpecker.Pipeline
+	path := fmt.Sprintf("/api/repos/%d/cron/%d", repoID, cronID)
+	if err := c.doJSON(ctx, http.MethodPost, path, nil, &pipeline); err != nil {
+		c.logger.WithFields(logrus.Fields{
+			"repo_id": repoID,
+			"cron_id": cronID,
+			"error":   err,
+		}).Error("Failed to run cron")
+		return nil, wrapError(err, "failed to run cron %d for repo %d", cronID, repoID)
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"repo_id":      repoID,
+		"cron_id":      cronID,
+		"pipeline_num": pipeline.Number,
+	}).Info("Started cron pipeline")
+	return &pipeline, nil
+}
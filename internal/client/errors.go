@@ -0,0 +1,189 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+// ErrorCode is a stable, machine-readable classification of a client failure
+type ErrorCode string
+
+const (
+	CodeNotFound     ErrorCode = "not_found"
+	CodeUnauthorized ErrorCode = "unauthorized"
+	CodeForbidden    ErrorCode = "forbidden"
+	CodeConflict     ErrorCode = "conflict"
+	CodeRateLimited  ErrorCode = "rate_limited"
+	CodeUnavailable  ErrorCode = "unavailable"
+	CodeValidation   ErrorCode = "validation"
+	CodeCancelled    ErrorCode = "cancelled"
+	// CodePolicyDenied is returned when this server's access policy, not Woodpecker, refused the call
+	CodePolicyDenied ErrorCode = "policy_denied"
+	CodeInternal     ErrorCode = "internal"
+)
+
+// Sentinel errors for use with errors.Is; only the code is compared
+var (
+	ErrNotFound     = &Error{Code: CodeNotFound}
+	ErrUnauthorized = &Error{Code: CodeUnauthorized}
+	ErrForbidden    = &Error{Code: CodeForbidden}
+	ErrConflict     = &Error{Code: CodeConflict}
+	ErrRateLimited  = &Error{Code: CodeRateLimited}
+	ErrUnavailable  = &Error{Code: CodeUnavailable}
+	ErrValidation   = &Error{Code: CodeValidation}
+	ErrCancelled    = &Error{Code: CodeCancelled}
+	ErrPolicyDenied = &Error{Code: CodePolicyDenied}
+)
+
+// Error is a classified failure returned by Client methods
+type Error struct {
+	Code       ErrorCode
+	StatusCode int
+	Message    string
+	Err        error
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Message == "" && e.Err == nil:
+		return string(e.Code)
+	case e.Err == nil:
+		return e.Message
+	case e.Message == "":
+		return e.Err.Error()
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches any *Error with the same code, so errors.Is(err, ErrNotFound) works
+func (e *Error) Is(target error) bool {
+	var t *Error
+	if !errors.As(target, &t) {
+		return false
+	}
+	return t.Code == e.Code
+}
+
+// Retryable reports whether repeating the same call later may succeed
+func (e *Error) Retryable() bool {
+	switch e.Code {
+	case CodeRateLimited, CodeUnavailable:
+		return true
+	}
+	return false
+}
+
+// Hint returns a short remediation suggestion aimed at the caller
+func (e *Error) Hint() string {
+	switch e.Code {
+	case CodeNotFound:
+		return "Check the repository, pipeline or step identifiers; list them first to find valid values."
+	case CodeUnauthorized:
+		return "The Woodpecker token is missing, invalid or expired; generate a new personal access token."
+	case CodeForbidden:
+		return "The token's user lacks permission for this action on this repository."
+	case CodeConflict:
+		return "The resource is in a state that does not allow this action; refresh its status and try again."
+	case CodeRateLimited:
+		return "Too many requests; wait before retrying."
+	case CodeUnavailable:
+		return "The Woodpecker server is unreachable or overloaded; retry after a short delay."
+	case CodeValidation:
+		return "Fix the arguments and call the tool again."
+	case CodeCancelled:
+		return "The request was cancelled before it completed."
+	case CodePolicyDenied:
+		return "This server's access policy does not allow this action on this repository or branch; ask an operator to change the policy."
+	}
+	return "Unexpected error; check the server logs for details."
+}
+
+// Validationf returns a CodeValidation error for invalid caller input
+func Validationf(format string, args ...interface{}) error {
+	return &Error{Code: CodeValidation, Message: fmt.Sprintf(format, args...)}
+}
+
+// CodeOf returns the classification of err, or CodeInternal if it was never classified
+func CodeOf(err error) ErrorCode {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return classify(err)
+}
+
+// AsError returns err as an *Error, classifying it if necessary
+func AsError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return &Error{Code: classify(err), Err: err}
+}
+
+// wrapError classifies err and prefixes it with a description of the failed operation
+func wrapError(err error, format string, args ...interface{}) error {
+	e := &Error{Code: classify(err), Message: fmt.Sprintf(format, args...), Err: err}
+
+	var clientErr *woodpecker.ClientError
+	if errors.As(err, &clientErr) {
+		e.StatusCode = clientErr.StatusCode
+	}
+
+	return e
+}
+
+func classify(err error) ErrorCode {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+
+	var clientErr *woodpecker.ClientError
+	if errors.As(err, &clientErr) {
+		return codeForStatus(clientErr.StatusCode)
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return CodeCancelled
+	case errors.Is(err, context.DeadlineExceeded):
+		return CodeUnavailable
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return CodeUnavailable
+	}
+
+	return CodeInternal
+}
+
+func codeForStatus(status int) ErrorCode {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return CodeValidation
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return CodeUnavailable
+	}
+	return CodeInternal
+}
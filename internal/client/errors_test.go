@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+func TestWrapError_ClassifiesStatusCodes(t *testing.T) {
+	cases := map[int]ErrorCode{
+		http.StatusBadRequest:          CodeValidation,
+		http.StatusUnauthorized:        CodeUnauthorized,
+		http.StatusForbidden:           CodeForbidden,
+		http.StatusNotFound:            CodeNotFound,
+		http.StatusConflict:            CodeConflict,
+		http.StatusUnprocessableEntity: CodeValidation,
+		http.StatusTooManyRequests:     CodeRateLimited,
+		http.StatusBadGateway:          CodeUnavailable,
+		http.StatusServiceUnavailable:  CodeUnavailable,
+		http.StatusGatewayTimeout:      CodeUnavailable,
+		http.StatusInternalServerError: CodeInternal,
+	}
+
+	for status, code := range cases {
+		err := wrapError(&woodpecker.ClientError{StatusCode: status, Message: "upstream"}, "failed to get repository %d", 1)
+
+		var e *Error
+		require.ErrorAs(t, err, &e)
+		require.Equal(t, code, e.Code, "status %d", status)
+		require.Equal(t, status, e.StatusCode)
+		require.Contains(t, err.Error(), "failed to get repository 1")
+	}
+}
+
+func TestWrapError_ContextErrors(t *testing.T) {
+	require.Equal(t, CodeCancelled, CodeOf(wrapError(context.Canceled, "op")))
+	require.Equal(t, CodeUnavailable, CodeOf(wrapError(context.DeadlineExceeded, "op")))
+	require.Equal(t, CodeInternal, CodeOf(errors.New("boom")))
+}
+
+func TestError_IsSentinel(t *testing.T) {
+	err := fmt.Errorf("lookup: %w", wrapError(&woodpecker.ClientError{StatusCode: http.StatusNotFound}, "failed"))
+
+	require.ErrorIs(t, err, ErrNotFound)
+	require.NotErrorIs(t, err, ErrForbidden)
+	require.False(t, AsError(err).Retryable())
+	require.True(t, AsError(wrapError(&woodpecker.ClientError{StatusCode: http.StatusTooManyRequests}, "failed")).Retryable())
+}
+
+func TestNew_UnauthorizedIsClassified(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := New(Config{URL: server.URL, Token: "bad-token"}, logrus.New())
+
+	require.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestNew_MissingURLIsValidation(t *testing.T) {
+	_, err := New(Config{Token: "token"}, logrus.New())
+
+	require.ErrorIs(t, err, ErrValidation)
+}
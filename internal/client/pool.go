@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+)
+
+// maxPoolSize bounds how many per-token clients, and how many rejected tokens, are kept at once
+const maxPoolSize = 256
+
+// rejectedTokenTTL is how long a token Woodpecker refused is refused again without asking it
+var rejectedTokenTTL = 30 * time.Second
+
+type tokenKey struct{}
+
+// WithToken returns a context carrying the caller's Woodpecker token
+func WithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
+// TokenFromContext returns the caller's Woodpecker token set by WithToken
+func TokenFromContext(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(tokenKey{}).(string)
+	return token, ok && token != ""
+}
+
+// Pool hands out one Client per Woodpecker token so that callers of a shared
+// server act with their own identity and permissions
+type Pool struct {
+	base     Config
+	logger   *logrus.Logger
+	mu       sync.Mutex
+	clients  map[[sha256.Size]byte]*Client
+	rejected map[[sha256.Size]byte]rejectedToken
+	// creating lets concurrent first requests with one token share a single verification
+	creating singleflight.Group
+}
+
+// rejectedToken is the error a token was refused with, remembered until expires
+type rejectedToken struct {
+	err     error
+	expires time.Time
+}
+
+// NewPool creates a Pool; base supplies everything except the token
+func NewPool(base Config, logger *logrus.Logger) *Pool {
+	return &Pool{
+		base:     base,
+		logger:   logger,
+		clients:  make(map[[sha256.Size]byte]*Client),
+		rejected: make(map[[sha256.Size]byte]rejectedToken),
+	}
+}
+
+// Get returns the Client for token, creating and verifying it on first use
+func (p *Pool) Get(ctx context.Context, token string) (*Client, error) {
+	if token == "" {
+		return nil, &Error{Code: CodeUnauthorized, Message: "no Woodpecker token supplied for this request"}
+	}
+
+	// Key by hash so raw tokens are not used as map keys
+	key := sha256.Sum256([]byte(token))
+
+	p.mu.Lock()
+	c, ok := p.clients[key]
+	rejected, refused := p.rejected[key]
+	p.mu.Unlock()
+	if ok {
+		return c, nil
+	}
+	if refused && time.Now().Before(rejected.expires) {
+		return nil, rejected.err
+	}
+
+	// Callers joining a verification in progress share its outcome, including a
+	// cancellation of the context it was started with
+	v, err, _ := p.creating.Do(string(key[:]), func() (interface{}, error) {
+		return p.create(ctx, key, token)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*Client), nil
+}
+
+// create verifies a new Client for token and caches it, or remembers the token
+// for rejectedTokenTTL if Woodpecker refused it
+func (p *Pool) create(ctx context.Context, key [sha256.Size]byte, token string) (*Client, error) {
+	cfg := p.base
+	cfg.Token = token
+	c, err := NewWithContext(ctx, cfg, p.logger)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		if CodeOf(err) == CodeUnauthorized {
+			if len(p.rejected) >= maxPoolSize {
+				for k := range p.rejected {
+					delete(p.rejected, k)
+					break
+				}
+			}
+			p.rejected[key] = rejectedToken{err: err, expires: time.Now().Add(rejectedTokenTTL)}
+		}
+		return nil, err
+	}
+	delete(p.rejected, key)
+
+	if existing, ok := p.clients[key]; ok {
+		return existing, nil
+	}
+	if len(p.clients) >= maxPoolSize {
+		// Evict an arbitrary entry; it is recreated on the caller's next request
+		for k := range p.clients {
+			delete(p.clients, k)
+			break
+		}
+	}
+	p.clients[key] = c
+
+	return c, nil
+}
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPool_ClientPerToken(t *testing.T) {
+	var selfCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		selfCalls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Header.Get("Authorization") {
+		case "Bearer alice-token":
+			_, _ = w.Write([]byte(`{"id": 1, "login": "alice"}`))
+		case "Bearer bob-token":
+			_, _ = w.Write([]byte(`{"id": 2, "login": "bob"}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	pool := NewPool(Config{URL: server.URL}, logrus.New())
+	ctx := context.Background()
+
+	alice, err := pool.Get(ctx, "alice-token")
+	require.NoError(t, err)
+	bob, err := pool.Get(ctx, "bob-token")
+	require.NoError(t, err)
+	require.NotSame(t, alice, bob)
+
+	again, err := pool.Get(ctx, "alice-token")
+	require.NoError(t, err)
+	require.Same(t, alice, again)
+
+	user, err := bob.GetCurrentUser(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "bob", user.Login)
+
+	// Two connection checks plus the explicit user lookup; the cached client is not re-verified
+	require.Equal(t, int32(3), selfCalls.Load())
+}
+
+func TestPool_RejectsInvalidToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	pool := NewPool(Config{URL: server.URL}, logrus.New())
+
+	_, err := pool.Get(context.Background(), "bad-token")
+	require.ErrorIs(t, err, ErrUnauthorized)
+
+	_, err = pool.Get(context.Background(), "")
+	require.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestPool_RemembersRejectedTokens(t *testing.T) {
+	var selfCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		selfCalls.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	ttl := rejectedTokenTTL
+	t.Cleanup(func() { rejectedTokenTTL = ttl })
+	rejectedTokenTTL = time.Hour
+
+	pool := NewPool(Config{URL: server.URL}, logrus.New())
+	for i := 0; i < 3; i++ {
+		_, err := pool.Get(context.Background(), "revoked-token")
+		require.ErrorIs(t, err, ErrUnauthorized)
+	}
+	require.Equal(t, int32(1), selfCalls.Load())
+
+	// Once the entry expires Woodpecker is asked again
+	rejectedTokenTTL = 0
+	_, err := pool.Get(context.Background(), "other-token")
+	require.ErrorIs(t, err, ErrUnauthorized)
+	_, err = pool.Get(context.Background(), "other-token")
+	require.ErrorIs(t, err, ErrUnauthorized)
+	require.Equal(t, int32(3), selfCalls.Load())
+}
+
+func TestPool_SharesConcurrentVerification(t *testing.T) {
+	var selfCalls atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		selfCalls.Add(1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": 1, "login": "alice"}`))
+	}))
+	defer server.Close()
+
+	pool := NewPool(Config{URL: server.URL}, logrus.New())
+
+	var wg sync.WaitGroup
+	clients := make([]*Client, 5)
+	for i := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c, err := pool.Get(context.Background(), "alice-token")
+			require.NoError(t, err)
+			clients[i] = c
+		}()
+	}
+	// Let every caller reach the pool before the verification completes
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	require.Equal(t, int32(1), selfCalls.Load())
+	for _, c := range clients {
+		require.Same(t, clients[0], c)
+	}
+}
+
+func TestPool_VerificationFollowsContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	pool := NewPool(Config{URL: server.URL, RetryMaxAttempts: 1}, logrus.New())
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := pool.Get(ctx, "slow-token")
+	require.ErrorIs(t, err, ErrCancelled)
+	require.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestTokenFromContext(t *testing.T) {
+	_, ok := TokenFromContext(context.Background())
+	require.False(t, ok)
+
+	token, ok := TokenFromContext(WithToken(context.Background(), "secret"))
+	require.True(t, ok)
+	require.Equal(t, "secret", token)
+}
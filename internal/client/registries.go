@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+// Registry credentials are scoped like secrets, but only to a repository or an
+// organization. Woodpecker never returns stored passwords.
+
+func registryScopeError(scope SecretScope) error {
+	return Validationf("registry credentials are managed per repository or organization, not at %s level", scope.Level)
+}
+
+func (c *Client) ListRegistries(ctx context.Context, scope SecretScope) ([]*woodpecker.Registry, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+
+	api := c.api(ctx)
+	var registries []*woodpecker.Registry
+	var err error
+	switch scope.Level {
+	case SecretLevelRepo:
+		registries, err = api.RegistryList(scope.ID, woodpecker.RegistryListOptions{})
+	case SecretLevelOrg:
+		registries, err = api.OrgRegistryList(scope.ID, woodpecker.RegistryListOptions{})
+	default:
+		return nil, registryScopeError(scope)
+	}
+	if err != nil {
+		c.logger.WithFields(scope.logFields()).WithError(err).Error("Failed to list registries")
+		return nil, wrapError(err, "failed to list %s registries", scope)
+	}
+
+	return registries, nil
+}
+
+func (c *Client) CreateRegistry(ctx context.Context, scope SecretScope, registry *woodpecker.Registry) (*woodpecker.Registry, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+
+	api := c.api(ctx)
+	var created *woodpecker.Registry
+	var err error
+	switch scope.Level {
+	case SecretLevelRepo:
+		created, err = api.RegistryCreate(scope.ID, registry)
+	case SecretLevelOrg:
+		created, err = api.OrgRegistryCreate(scope.ID, registry)
+	default:
+		return nil, registryScopeError(scope)
+	}
+	if err != nil {
+		c.logger.WithFields(scope.logFields()).WithField("registry", registry.Address).WithError(err).Error("Failed to create registry")
+		return nil, wrapError(err, "failed to create %s registry %s", scope, registry.Address)
+	}
+
+	c.logger.WithFields(scope.logFields()).WithField("registry", registry.Address).Info("Created registry")
+	return created, nil
+}
+
+// UpdateRegistry changes the credentials for registry.Address; empty fields keep their current value
+func (c *Client) UpdateRegistry(ctx context.Context, scope SecretScope, registry *woodpecker.Registry) (*woodpecker.Registry, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+
+	api := c.api(ctx)
+	var updated *woodpecker.Registry
+	var err error
+	switch scope.Level {
+	case SecretLevelRepo:
+		updated, err = api.RegistryUpdate(scope.ID, registry)
+	case SecretLevelOrg:
+		updated, err = api.OrgRegistryUpdate(scope.ID, registry)
+	default:
+		return nil, registryScopeError(scope)
+	}
+	if err != nil {
+		c.logger.WithFields(scope.logFields()).WithField("registry", registry.Address).WithError(err).Error("Failed to update registry")
+		return nil, wrapError(err, "failed to update %s registry %s", scope, registry.Address)
+	}
+
+	c.logger.WithFields(scope.logFields()).WithField("registry", registry.Address).Info("Updated registry")
+	return updated, nil
+}
+
+func (c *Client) DeleteRegistry(ctx context.Context, scope SecretScope, address string) error {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return err
+	}
+
+	api := c.api(ctx)
+	var err error
+	switch scope.Level {
+	case SecretLevelRepo:
+		err = api.RegistryDelete(scope.ID, address)
+	case SecretLevelOrg:
+		err = api.OrgRegistryDelete(scope.ID, address)
+	default:
+		return registryScopeError(scope)
+	}
+	if err != nil {
+		c.logger.WithFields(scope.logFields()).WithField("registry", address).WithError(err).Error("Failed to delete registry")
+		return wrapError(err, "failed to delete %s registry %s", scope, address)
+	}
+
+	c.logger.WithFields(scope.logFields()).WithField("registry", address).Info("Deleted registry")
+	return nil
+}
@@ -0,0 +1,162 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultRetryMaxAttempts = 3
+	defaultRetryMaxDelay    = 10 * time.Second
+	defaultRetryBaseDelay   = 500 * time.Millisecond
+)
+
+type retrySafeKey struct{}
+
+// withRetrySafe marks requests made with ctx as safe to retry even when they are
+// not idempotent by HTTP method, e.g. a POST that cancels a pipeline
+func withRetrySafe(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retrySafeKey{}, true)
+}
+
+// retryTransport retries idempotent requests that fail with a transient error
+// using jittered exponential backoff, honouring Retry-After when present
+type retryTransport struct {
+	base        http.RoundTripper
+	logger      *logrus.Logger
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.maxAttempts <= 1 || !isRetryable(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if attempt >= t.maxAttempts || !shouldRetry(req, resp, err) {
+			return resp, err
+		}
+
+		delay, ok := t.delay(attempt, resp)
+		if !ok {
+			return resp, err
+		}
+
+		t.logger.WithFields(logrus.Fields{
+			"method":  req.Method,
+			"url":     req.URL.String(),
+			"attempt": attempt,
+			"delay":   delay,
+			"status":  statusOf(resp),
+			"error":   err,
+		}).Warn("Retrying Woodpecker request")
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, bodyErr
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// delay returns how long to wait before the next attempt. It reports false when the
+// server asked us to wait longer than maxDelay, in which case retrying is pointless.
+func (t *retryTransport) delay(attempt int, resp *http.Response) (time.Duration, bool) {
+	if resp != nil {
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			if wait > t.maxDelay {
+				return 0, false
+			}
+			return wait, true
+		}
+	}
+
+	backoff := t.baseDelay << (attempt - 1)
+	if backoff <= 0 || backoff > t.maxDelay {
+		backoff = t.maxDelay
+	}
+	// Full jitter: pick uniformly between zero and the exponential ceiling
+	return time.Duration(rand.Int64N(int64(backoff) + 1)), true
+}
+
+// isRetryable reports whether req may be sent more than once
+func isRetryable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	safe, _ := req.Context().Value(retrySafeKey{}).(bool)
+	return safe
+}
+
+func shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		// Never retry once the caller has given up
+		return req.Context().Err() == nil && !errors.Is(err, context.Canceled)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter understands both forms of the Retry-After header: delay-seconds and HTTP-date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		wait := at.Sub(now)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+func statusOf(resp *http.Response) int {
+	if resp == nil {
+		return 0
+	}
+	return resp.StatusCode
+}
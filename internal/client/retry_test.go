@@ -0,0 +1,211 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRetryTransport(maxAttempts int) *retryTransport {
+	return &retryTransport{
+		base:        http.DefaultTransport,
+		logger:      logrus.New(),
+		maxAttempts: maxAttempts,
+		baseDelay:   time.Millisecond,
+		maxDelay:    50 * time.Millisecond,
+	}
+}
+
+// flakyServer fails the first failures requests with status, then succeeds
+func flakyServer(failures int32, status int, header http.Header, calls *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(body)
+	}))
+}
+
+func TestRetryTransport_RetriesTransientStatus(t *testing.T) {
+	for _, status := range []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout, http.StatusTooManyRequests} {
+		var calls atomic.Int32
+		server := flakyServer(2, status, nil, &calls)
+
+		httpClient := &http.Client{Transport: newTestRetryTransport(3)}
+		resp, err := httpClient.Get(server.URL)
+
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, int32(3), calls.Load())
+		_ = resp.Body.Close()
+		server.Close()
+	}
+}
+
+func TestRetryTransport_GivesUpAfterMaxAttempts(t *testing.T) {
+	var calls atomic.Int32
+	server := flakyServer(10, http.StatusServiceUnavailable, nil, &calls)
+	defer server.Close()
+
+	httpClient := &http.Client{Transport: newTestRetryTransport(3)}
+	resp, err := httpClient.Get(server.URL)
+
+	require.NoError(t, err)
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	require.Equal(t, int32(3), calls.Load())
+	_ = resp.Body.Close()
+}
+
+func TestRetryTransport_DoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := flakyServer(10, http.StatusNotFound, nil, &calls)
+	defer server.Close()
+
+	httpClient := &http.Client{Transport: newTestRetryTransport(3)}
+	resp, err := httpClient.Get(server.URL)
+
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	require.Equal(t, int32(1), calls.Load())
+	_ = resp.Body.Close()
+}
+
+func TestRetryTransport_DoesNotRetryPlainPost(t *testing.T) {
+	var calls atomic.Int32
+	server := flakyServer(10, http.StatusBadGateway, nil, &calls)
+	defer server.Close()
+
+	httpClient := &http.Client{Transport: newTestRetryTransport(3)}
+	resp, err := httpClient.Post(server.URL, "application/json", strings.NewReader(`{}`))
+
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	require.Equal(t, int32(1), calls.Load())
+	_ = resp.Body.Close()
+}
+
+func TestRetryTransport_RetriesSafePostWithBody(t *testing.T) {
+	var calls atomic.Int32
+	server := flakyServer(1, http.StatusBadGateway, nil, &calls)
+	defer server.Close()
+
+	req, err := http.NewRequestWithContext(withRetrySafe(context.Background()), http.MethodPost, server.URL, strings.NewReader(`{"a":1}`))
+	require.NoError(t, err)
+
+	httpClient := &http.Client{Transport: newTestRetryTransport(3)}
+	resp, err := httpClient.Do(req)
+
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, int32(2), calls.Load())
+	body, _ := io.ReadAll(resp.Body)
+	require.Equal(t, `{"a":1}`, string(body))
+	_ = resp.Body.Close()
+}
+
+func TestRetryTransport_HonoursRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	server := flakyServer(1, http.StatusTooManyRequests, http.Header{"Retry-After": []string{"1"}}, &calls)
+	defer server.Close()
+
+	transport := newTestRetryTransport(2)
+	transport.maxDelay = 2 * time.Second
+	httpClient := &http.Client{Transport: transport}
+
+	start := time.Now()
+	resp, err := httpClient.Get(server.URL)
+
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.GreaterOrEqual(t, time.Since(start), time.Second)
+	_ = resp.Body.Close()
+}
+
+func TestRetryTransport_RetryAfterBeyondMaxDelay(t *testing.T) {
+	var calls atomic.Int32
+	server := flakyServer(1, http.StatusTooManyRequests, http.Header{"Retry-After": []string{"120"}}, &calls)
+	defer server.Close()
+
+	httpClient := &http.Client{Transport: newTestRetryTransport(3)}
+	resp, err := httpClient.Get(server.URL)
+
+	require.NoError(t, err)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Equal(t, int32(1), calls.Load())
+	_ = resp.Body.Close()
+}
+
+func TestRetryTransport_RetriesConnectionErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			// Drop the connection without a response to simulate a reset
+			conn, _, err := w.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			_ = conn.Close()
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	httpClient := &http.Client{Transport: newTestRetryTransport(3)}
+	resp, err := httpClient.Get(server.URL)
+
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, int32(2), calls.Load())
+	_ = resp.Body.Close()
+}
+
+func TestRetryTransport_StopsWhenContextCancelled(t *testing.T) {
+	var calls atomic.Int32
+	server := flakyServer(10, http.StatusServiceUnavailable, http.Header{"Retry-After": []string{"1"}}, &calls)
+	defer server.Close()
+
+	transport := newTestRetryTransport(5)
+	transport.maxDelay = 5 * time.Second
+	httpClient := &http.Client{Transport: transport}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	_, err = httpClient.Do(req)
+
+	require.Error(t, err)
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	wait, ok := parseRetryAfter("5", now)
+	require.True(t, ok)
+	require.Equal(t, 5*time.Second, wait)
+
+	wait, ok = parseRetryAfter(now.Add(30*time.Second).Format(http.TimeFormat), now)
+	require.True(t, ok)
+	require.Equal(t, 30*time.Second, wait)
+
+	_, ok = parseRetryAfter("", now)
+	require.False(t, ok)
+
+	_, ok = parseRetryAfter("soon", now)
+	require.False(t, ok)
+}
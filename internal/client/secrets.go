@@ -0,0 +1,172 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+// Secret levels, from the most to the least specific. When a pipeline sees the
+// same name at several levels, the most specific one wins.
+const (
+	SecretLevelRepo   = "repo"
+	SecretLevelOrg    = "org"
+	SecretLevelGlobal = "global"
+)
+
+// SecretScope selects where secrets are read or written
+type SecretScope struct {
+	Level string
+	// ID is the repository or organization ID; it is unused for global secrets
+	ID int64
+}
+
+func RepoSecrets(repoID int64) SecretScope {
+	return SecretScope{Level: SecretLevelRepo, ID: repoID}
+}
+
+func OrgSecrets(orgID int64) SecretScope {
+	return SecretScope{Level: SecretLevelOrg, ID: orgID}
+}
+
+func GlobalSecrets() SecretScope {
+	return SecretScope{Level: SecretLevelGlobal}
+}
+
+func (s SecretScope) String() string {
+	if s.Level == SecretLevelGlobal {
+		return "global"
+	}
+	return fmt.Sprintf("%s %d", s.Level, s.ID)
+}
+
+func (s SecretScope) logFields() logrus.Fields {
+	return logrus.Fields{"scope": s.String()}
+}
+
+// LookupOrg finds an organization, or the user namespace, by name
+func (c *Client) LookupOrg(ctx context.Context, name string) (*woodpecker.Org, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	org, err := c.api(ctx).OrgLookup(name)
+	if err != nil {
+		c.logger.WithFields(logrus.Fields{
+			"org":   name,
+			"error": err,
+		}).Error("Failed to lookup organization")
+		return nil, wrapError(err, "failed to lookup organization %s", name)
+	}
+
+	return org, nil
+}
+
+// ListSecrets returns the secrets at scope; Woodpecker never includes their values
+func (c *Client) ListSecrets(ctx context.Context, scope SecretScope) ([]*woodpecker.Secret, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+
+	api := c.api(ctx)
+	var secrets []*woodpecker.Secret
+	var err error
+	switch scope.Level {
+	case SecretLevelRepo:
+		secrets, err = api.SecretList(scope.ID, woodpecker.SecretListOptions{})
+	case SecretLevelOrg:
+		secrets, err = api.OrgSecretList(scope.ID, woodpecker.SecretListOptions{})
+	case SecretLevelGlobal:
+		secrets, err = api.GlobalSecretList(woodpecker.SecretListOptions{})
+	default:
+		return nil, Validationf("unknown secret level %q", scope.Level)
+	}
+	if err != nil {
+		c.logger.WithFields(scope.logFields()).WithError(err).Error("Failed to list secrets")
+		return nil, wrapError(err, "failed to list %s secrets", scope)
+	}
+
+	return secrets, nil
+}
+
+func (c *Client) CreateSecret(ctx context.Context, scope SecretScope, secret *woodpecker.Secret) (*woodpecker.Secret, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+
+	api := c.api(ctx)
+	var created *woodpecker.Secret
+	var err error
+	switch scope.Level {
+	case SecretLevelRepo:
+		created, err = api.SecretCreate(scope.ID, secret)
+	case SecretLevelOrg:
+		created, err = api.OrgSecretCreate(scope.ID, secret)
+	case SecretLevelGlobal:
+		created, err = api.GlobalSecretCreate(secret)
+	default:
+		return nil, Validationf("unknown secret level %q", scope.Level)
+	}
+	if err != nil {
+		c.logger.WithFields(scope.logFields()).WithField("secret", secret.Name).WithError(err).Error("Failed to create secret")
+		return nil, wrapError(err, "failed to create %s secret %s", scope, secret.Name)
+	}
+
+	c.logger.WithFields(scope.logFields()).WithField("secret", secret.Name).Info("Created secret")
+	return created, nil
+}
+
+// UpdateSecret changes the secret named secret.Name; empty fields keep their current value
+func (c *Client) UpdateSecret(ctx context.Context, scope SecretScope, secret *woodpecker.Secret) (*woodpecker.Secret, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+
+	api := c.api(ctx)
+	var updated *woodpecker.Secret
+	var err error
+	switch scope.Level {
+	case SecretLevelRepo:
+		updated, err = api.SecretUpdate(scope.ID, secret)
+	case SecretLevelOrg:
+		updated, err = api.OrgSecretUpdate(scope.ID, secret)
+	case SecretLevelGlobal:
+		updated, err = api.GlobalSecretUpdate(secret)
+	default:
+		return nil, Validationf("unknown secret level %q", scope.Level)
+	}
+	if err != nil {
+		c.logger.WithFields(scope.logFields()).WithField("secret", secret.Name).WithError(err).Error("Failed to update secret")
+		return nil, wrapError(err, "failed to update %s secret %s", scope, secret.Name)
+	}
+
+	c.logger.WithFields(scope.logFields()).WithField("secret", secret.Name).Info("Updated secret")
+	return updated, nil
+}
+
+func (c *Client) DeleteSecret(ctx context.Context, scope SecretScope, name string) error {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return err
+	}
+
+	api := c.api(ctx)
+	var err error
+	switch scope.Level {
+	case SecretLevelRepo:
+		err = api.SecretDelete(scope.ID, name)
+	case SecretLevelOrg:
+		err = api.OrgSecretDelete(scope.ID, name)
+	case SecretLevelGlobal:
+		err = api.GlobalSecretDelete(name)
+	default:
+		return Validationf("unknown secret level %q", scope.Level)
+	}
+	if err != nil {
+		c.logger.WithFields(scope.logFields()).WithField("secret", name).WithError(err).Error("Failed to delete secret")
+		return wrapError(err, "failed to delete %s secret %s", scope, name)
+	}
+
+	c.logger.WithFields(scope.logFields()).WithField("secret", name).Info("Deleted secret")
+	return nil
+}
@@ -0,0 +1,155 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+const (
+	// streamReconnectAttempts is how many consecutive failed connections are
+	// tolerated before FollowStepLogs gives up
+	streamReconnectAttempts = 5
+	// maxStreamLineSize bounds a single SSE line, i.e. one encoded log entry
+	maxStreamLineSize = 1024 * 1024
+)
+
+// streamReconnectDelay is the pause before the first reconnect; it grows linearly
+var streamReconnectDelay = time.Second
+
+// errStreamClosed is returned when the server closed the stream without signalling the end of the step
+var errStreamClosed = errors.New("log stream closed unexpectedly")
+
+// FollowStepLogs subscribes to the live log of a step and calls fn for every entry
+// whose line number is greater than afterLine. Dropped connections are re-established
+// and lines already delivered are skipped. It returns nil once Woodpecker reports
+// that the step has finished, or an error when ctx ends or the stream keeps failing.
+func (c *Client) FollowStepLogs(ctx context.Context, repoID, pipelineNum, stepID int64, afterLine int, fn func(*woodpecker.LogEntry)) error {
+	url := fmt.Sprintf("%s/api/stream/logs/%d/%d/%d", strings.TrimRight(c.url, "/"), repoID, pipelineNum, stepID)
+	lastLine := afterLine
+
+	failures := 0
+	for {
+		if err := c.waitForRateLimit(ctx); err != nil {
+			return err
+		}
+
+		delivered := false
+		done, err := c.streamOnce(ctx, url, func(entry *woodpecker.LogEntry) {
+			if int(entry.Line) <= lastLine {
+				return
+			}
+			lastLine = int(entry.Line)
+			delivered = true
+			fn(entry)
+		})
+		if done {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return wrapError(ctx.Err(), "stopped following logs for step %d", stepID)
+		}
+
+		if e := AsError(err); e.StatusCode >= 400 && e.StatusCode < 500 && e.StatusCode != http.StatusTooManyRequests {
+			return wrapError(err, "failed to follow logs for step %d in pipeline %d for repo %d", stepID, pipelineNum, repoID)
+		}
+
+		if delivered {
+			failures = 0
+		}
+		failures++
+		if failures > streamReconnectAttempts {
+			return wrapError(err, "failed to follow logs for step %d in pipeline %d for repo %d", stepID, pipelineNum, repoID)
+		}
+
+		delay := time.Duration(failures) * streamReconnectDelay
+		c.logger.WithFields(logrus.Fields{
+			"step_id":   stepID,
+			"attempt":   failures,
+			"last_line": lastLine,
+			"delay":     delay,
+		}).WithError(err).Warn("Log stream interrupted, reconnecting")
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return wrapError(ctx.Err(), "stopped following logs for step %d", stepID)
+		case <-timer.C:
+		}
+	}
+}
+
+// streamOnce reads one SSE connection; done is true when the server signalled the end of the step
+func (c *Client) streamOnce(ctx context.Context, url string, fn func(*woodpecker.LogEntry)) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	// The shared client's timeout would cut long-running streams; ctx bounds this one
+	streamClient := &http.Client{Transport: c.httpClient.Transport}
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return false, wrapError(&woodpecker.ClientError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}, "log stream rejected")
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), maxStreamLineSize)
+
+	var event string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if len(data) == 0 {
+				event = ""
+				continue
+			}
+			payload := strings.Join(data, "\n")
+			if event == "error" {
+				// Woodpecker ends the stream with "eof", or says the step is no longer running
+				if payload == "eof" || strings.Contains(payload, "not running") {
+					return true, nil
+				}
+				return false, fmt.Errorf("log stream error: %s", payload)
+			}
+
+			var entry woodpecker.LogEntry
+			if err := json.Unmarshal([]byte(payload), &entry); err != nil {
+				c.logger.WithError(err).Debug("Skipping malformed log stream event")
+			} else {
+				fn(&entry)
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, ":"):
+			// Comment, used by Woodpecker as keep-alive
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return false, err
+	}
+	return false, errStreamClosed
+}
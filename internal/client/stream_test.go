@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+// sseServer is a stand-in for Woodpecker's log stream endpoint; stream is called
+// once per connection with the 1-based connection number
+func sseServer(t *testing.T, stream func(w http.ResponseWriter, conn int32)) (*Client, *atomic.Int32) {
+	t.Helper()
+
+	original := streamReconnectDelay
+	streamReconnectDelay = time.Millisecond
+	t.Cleanup(func() { streamReconnectDelay = original })
+
+	var conns atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/user":
+			_, _ = w.Write([]byte(`{"id": 1, "login": "testuser"}`))
+		case "/api/stream/logs/1/2/3":
+			require.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+			stream(w, conns.Add(1))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := New(Config{URL: server.URL, Token: "test-token", RetryMaxAttempts: 1}, logrus.New())
+	require.NoError(t, err)
+	return c, &conns
+}
+
+func writeLogEvent(w http.ResponseWriter, line int, text string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	_, _ = fmt.Fprintf(w, "data: {\"line\":%d,\"time\":%d,\"data\":%q,\"type\":0}\n\n", line, line, base64.StdEncoding.EncodeToString([]byte(text)))
+	w.(http.Flusher).Flush()
+}
+
+func followAll(t *testing.T, c *Client, afterLine int) ([]string, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var lines []string
+	err := c.FollowStepLogs(ctx, 1, 2, 3, afterLine, func(entry *woodpecker.LogEntry) {
+		lines = append(lines, string(entry.Data))
+	})
+	return lines, err
+}
+
+func TestFollowStepLogs_StopsAtEOF(t *testing.T) {
+	c, conns := sseServer(t, func(w http.ResponseWriter, conn int32) {
+		_, _ = w.Write([]byte(": ping\n\n"))
+		writeLogEvent(w, 0, "one")
+		writeLogEvent(w, 1, "two")
+		_, _ = w.Write([]byte("event: error\ndata: eof\n\n"))
+	})
+
+	lines, err := followAll(t, c, -1)
+
+	require.NoError(t, err)
+	require.Equal(t, []string{"one", "two"}, lines)
+	require.Equal(t, int32(1), conns.Load())
+}
+
+func TestFollowStepLogs_ReconnectsWithoutDuplicates(t *testing.T) {
+	c, conns := sseServer(t, func(w http.ResponseWriter, conn int32) {
+		writeLogEvent(w, 0, "one")
+		writeLogEvent(w, 1, "two")
+		if conn == 1 {
+			// Drop the connection before the step has finished
+			return
+		}
+		// Woodpecker replays the whole log to a new subscriber
+		writeLogEvent(w, 2, "three")
+		_, _ = w.Write([]byte("event: error\ndata: eof\n\n"))
+	})
+
+	lines, err := followAll(t, c, -1)
+
+	require.NoError(t, err)
+	require.Equal(t, []string{"one", "two", "three"}, lines)
+	require.Equal(t, int32(2), conns.Load())
+}
+
+func TestFollowStepLogs_SkipsLinesBeforeAfterLine(t *testing.T) {
+	c, _ := sseServer(t, func(w http.ResponseWriter, conn int32) {
+		writeLogEvent(w, 0, "one")
+		writeLogEvent(w, 1, "two")
+		_, _ = w.Write([]byte("event: error\ndata: step not running (anymore).\n\n"))
+	})
+
+	lines, err := followAll(t, c, 0)
+
+	require.NoError(t, err)
+	require.Equal(t, []string{"two"}, lines)
+}
+
+func TestFollowStepLogs_GivesUpAfterRepeatedDrops(t *testing.T) {
+	c, conns := sseServer(t, func(w http.ResponseWriter, conn int32) {
+		w.Header().Set("Content-Type", "text/event-stream")
+	})
+
+	_, err := followAll(t, c, -1)
+
+	require.Error(t, err)
+	require.Equal(t, int32(streamReconnectAttempts+1), conns.Load())
+}
+
+func TestFollowStepLogs_DoesNotRetryNotFound(t *testing.T) {
+	c, conns := sseServer(t, func(w http.ResponseWriter, conn int32) {
+		http.Error(w, "step not found", http.StatusNotFound)
+	})
+
+	_, err := followAll(t, c, -1)
+
+	require.ErrorIs(t, err, ErrNotFound)
+	require.Equal(t, int32(1), conns.Load())
+}
@@ -2,8 +2,11 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -12,15 +15,22 @@ import (
 )
 
 type Client struct {
-	client  woodpecker.Client
-	logger  *logrus.Logger
-	url     string
-	limiter *rate.Limiter
+	httpClient *http.Client
+	logger     *logrus.Logger
+	url        string
+	limiter    *rate.Limiter
+
+	mu    sync.Mutex
+	login string
 }
 
 type Config struct {
 	URL   string
 	Token string
+	// RetryMaxAttempts is the total number of attempts for retryable requests (default: 3, 1 disables retries)
+	RetryMaxAttempts int
+	// RetryMaxDelay caps the backoff between attempts and the Retry-After delay we are willing to honour (default: 10s)
+	RetryMaxDelay time.Duration
 }
 
 // bearerTokenTransport adds bearer token authentication to HTTP requests
@@ -34,36 +44,66 @@ func (t *bearerTokenTransport) RoundTrip(req *http.Request) (*http.Response, err
 	return t.base.RoundTrip(req)
 }
 
+// contextTransport binds outgoing HTTP requests to the context of the calling
+// client method, since woodpecker-go does not accept a context itself
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(req.WithContext(t.ctx))
+}
+
+// New creates a Client and verifies that it can reach Woodpecker with cfg.Token
 func New(cfg Config, logger *logrus.Logger) (*Client, error) {
+	return NewWithContext(context.Background(), cfg, logger)
+}
+
+// NewWithContext is New with the connection check bound to ctx, so that it is
+// abandoned when ctx is cancelled
+func NewWithContext(ctx context.Context, cfg Config, logger *logrus.Logger) (*Client, error) {
 	if cfg.URL == "" {
-		return nil, fmt.Errorf("woodpecker URL is required")
+		return nil, Validationf("woodpecker URL is required")
 	}
 	if cfg.Token == "" {
-		return nil, fmt.Errorf("woodpecker token is required")
+		return nil, Validationf("woodpecker token is required")
 	}
 
-	// Create HTTP client with bearer token authentication
+	maxAttempts := cfg.RetryMaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultRetryMaxAttempts
+	}
+	maxDelay := cfg.RetryMaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryMaxDelay
+	}
+
+	// Create HTTP client with bearer token authentication and retries for transient failures
 	httpClient := &http.Client{
 		Timeout: 30 * time.Second,
 		Transport: &bearerTokenTransport{
 			token: cfg.Token,
-			base:  http.DefaultTransport,
+			base: &retryTransport{
+				base:        http.DefaultTransport,
+				logger:      logger,
+				maxAttempts: maxAttempts,
+				baseDelay:   defaultRetryBaseDelay,
+				maxDelay:    maxDelay,
+			},
 		},
 	}
 
-	// Create Woodpecker client
-	client := woodpecker.NewClient(cfg.URL, httpClient)
-
 	wclient := &Client{
-		client:  client,
-		logger:  logger,
-		url:     cfg.URL,
-		limiter: rate.NewLimiter(rate.Limit(10), 20),
+		httpClient: httpClient,
+		logger:     logger,
+		url:        cfg.URL,
+		limiter:    rate.NewLimiter(rate.Limit(10), 20),
 	}
 
 	// Test connection
-	if err := wclient.TestConnection(); err != nil {
-		return nil, fmt.Errorf("failed to connect to Woodpecker server: %w", err)
+	if err := wclient.TestConnection(ctx); err != nil {
+		return nil, wrapError(err, "failed to connect to Woodpecker server")
 	}
 
 	logger.WithFields(logrus.Fields{
@@ -73,121 +113,216 @@ func New(cfg Config, logger *logrus.Logger) (*Client, error) {
 	return wclient, nil
 }
 
-// waitForRateLimit waits for rate limit permission before making API calls
-func (c *Client) waitForRateLimit() {
-	_ = c.limiter.Wait(context.Background())
+// api returns a Woodpecker client whose requests are cancelled together with ctx
+func (c *Client) api(ctx context.Context) woodpecker.Client {
+	return woodpecker.NewClient(c.url, &http.Client{
+		Timeout: c.httpClient.Timeout,
+		Transport: &contextTransport{
+			ctx:  ctx,
+			base: c.httpClient.Transport,
+		},
+	})
+}
+
+// waitForRateLimit waits for rate limit permission before making API calls.
+// It returns early with an error if ctx is cancelled while waiting.
+func (c *Client) waitForRateLimit(ctx context.Context) error {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return wrapError(err, "rate limit wait aborted")
+	}
+	return nil
 }
 
-func (c *Client) TestConnection() error {
+func (c *Client) TestConnection(ctx context.Context) error {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return err
+	}
+
 	// Try to get current user info to test connection
-	_, err := c.client.Self()
+	user, err := c.api(ctx).Self()
 	if err != nil {
-		return fmt.Errorf("connection test failed: %w", err)
+		return wrapError(err, "connection test failed")
 	}
 
+	c.mu.Lock()
+	c.login = user.Login
+	c.mu.Unlock()
+
 	return nil
 }
 
-func (c *Client) ListRepositories() ([]*woodpecker.Repo, error) {
-	c.waitForRateLimit()
-	repos, err := c.client.RepoList(woodpecker.RepoListOptions{})
+// Login returns the Woodpecker user the token belongs to, as seen by the last
+// successful TestConnection
+func (c *Client) Login() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.login
+}
+
+func (c *Client) ListRepositories(ctx context.Context) ([]*woodpecker.Repo, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	repos, err := c.api(ctx).RepoList(woodpecker.RepoListOptions{})
 	if err != nil {
 		c.logger.WithError(err).Error("Failed to list repositories")
-		return nil, fmt.Errorf("failed to list repositories: %w", err)
+		return nil, wrapError(err, "failed to list repositories")
 	}
 
 	c.logger.WithField("count", len(repos)).Debug("Listed repositories")
 	return repos, nil
 }
 
-func (c *Client) GetRepository(repoID int64) (*woodpecker.Repo, error) {
-	c.waitForRateLimit()
-	repo, err := c.client.Repo(repoID)
+func (c *Client) GetRepository(ctx context.Context, repoID int64) (*woodpecker.Repo, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	repo, err := c.api(ctx).Repo(repoID)
 	if err != nil {
 		c.logger.WithFields(logrus.Fields{
 			"repo_id": repoID,
 			"error":   err,
 		}).Error("Failed to get repository")
-		return nil, fmt.Errorf("failed to get repository %d: %w", repoID, err)
+		return nil, wrapError(err, "failed to get repository %d", repoID)
 	}
 
 	return repo, nil
 }
 
-func (c *Client) LookupRepository(fullName string) (*woodpecker.Repo, error) {
-	c.waitForRateLimit()
-	repo, err := c.client.RepoLookup(fullName)
+func (c *Client) LookupRepository(ctx context.Context, fullName string) (*woodpecker.Repo, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	repo, err := c.api(ctx).RepoLookup(fullName)
 	if err != nil {
 		c.logger.WithFields(logrus.Fields{
 			"repo_name": fullName,
 			"error":     err,
 		}).Error("Failed to lookup repository")
-		return nil, fmt.Errorf("failed to lookup repository %s: %w", fullName, err)
+		return nil, wrapError(err, "failed to lookup repository %s", fullName)
 	}
 
 	return repo, nil
 }
 
-func (c *Client) ListPipelines(repoID int64) ([]*woodpecker.Pipeline, error) {
-	c.waitForRateLimit()
-	pipelines, err := c.client.PipelineList(repoID, woodpecker.PipelineListOptions{})
+// branchPageSize and maxBranchPages bound how much of a repository's branch list HasBranch reads
+const (
+	branchPageSize = 50
+	maxBranchPages = 40
+)
+
+// ErrBranchUnverified is returned by HasBranch when branch is not in the part of a
+// longer branch list it reads, so whether it exists is unknown
+var ErrBranchUnverified = errors.New("branch list is too long to check")
+
+// HasBranch reports whether the repository's forge has branch, paging through the
+// branch list until it is found
+func (c *Client) HasBranch(ctx context.Context, repoID int64, branch string) (bool, error) {
+	for page := 1; page <= maxBranchPages; page++ {
+		if err := c.waitForRateLimit(ctx); err != nil {
+			return false, err
+		}
+
+		var branches []string
+		path := fmt.Sprintf("/api/repos/%d/branches?page=%d&perPage=%d", repoID, page, branchPageSize)
+		if err := c.doJSON(ctx, http.MethodGet, path, nil, &branches); err != nil {
+			c.logger.WithFields(logrus.Fields{
+				"repo_id": repoID,
+				"error":   err,
+			}).Error("Failed to list branches")
+			return false, wrapError(err, "failed to list branches for repo %d", repoID)
+		}
+
+		for _, b := range branches {
+			if b == branch {
+				return true, nil
+			}
+		}
+		if len(branches) < branchPageSize {
+			return false, nil
+		}
+	}
+
+	return false, fmt.Errorf("%w: %q is not among the first %d branches of repo %d", ErrBranchUnverified, branch, maxBranchPages*branchPageSize, repoID)
+}
+
+// PipelineURL returns the link to a pipeline in the Woodpecker web UI
+func (c *Client) PipelineURL(repoID, pipelineNum int64) string {
+	return fmt.Sprintf("%s/repos/%d/pipeline/%d", strings.TrimRight(c.url, "/"), repoID, pipelineNum)
+}
+
+// ListPipelines fetches a single page of pipelines matching opt.
+// Filters (branch, events, status, before/after) are applied server-side.
+func (c *Client) ListPipelines(ctx context.Context, repoID int64, opt woodpecker.PipelineListOptions) ([]*woodpecker.Pipeline, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	pipelines, err := c.api(ctx).PipelineList(repoID, opt)
 	if err != nil {
 		c.logger.WithFields(logrus.Fields{
 			"repo_id": repoID,
 			"error":   err,
 		}).Error("Failed to list pipelines")
-		return nil, fmt.Errorf("failed to list pipelines for repo %d: %w", repoID, err)
+		return nil, wrapError(err, "failed to list pipelines for repo %d", repoID)
 	}
 
 	c.logger.WithFields(logrus.Fields{
-		"repo_id": repoID,
-		"count":   len(pipelines),
+		"repo_id":  repoID,
+		"page":     opt.Page,
+		"per_page": opt.PerPage,
+		"count":    len(pipelines),
 	}).Debug("Listed pipelines")
 	return pipelines, nil
 }
 
-func (c *Client) GetPipeline(repoID, pipelineNum int64) (*woodpecker.Pipeline, error) {
-	c.waitForRateLimit()
-	pipeline, err := c.client.Pipeline(repoID, pipelineNum)
+func (c *Client) GetPipeline(ctx context.Context, repoID, pipelineNum int64) (*woodpecker.Pipeline, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	pipeline, err := c.api(ctx).Pipeline(repoID, pipelineNum)
 	if err != nil {
 		c.logger.WithFields(logrus.Fields{
 			"repo_id":      repoID,
 			"pipeline_num": pipelineNum,
 			"error":        err,
 		}).Error("Failed to get pipeline")
-		return nil, fmt.Errorf("failed to get pipeline %d for repo %d: %w", pipelineNum, repoID, err)
+		return nil, wrapError(err, "failed to get pipeline %d for repo %d", pipelineNum, repoID)
 	}
 
 	return pipeline, nil
 }
 
-func (c *Client) GetLastPipeline(repoID int64) (*woodpecker.Pipeline, error) {
-	c.waitForRateLimit()
-	pipeline, err := c.client.PipelineLast(repoID, woodpecker.PipelineLastOptions{})
+func (c *Client) GetLastPipeline(ctx context.Context, repoID int64) (*woodpecker.Pipeline, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	pipeline, err := c.api(ctx).PipelineLast(repoID, woodpecker.PipelineLastOptions{})
 	if err != nil {
 		c.logger.WithFields(logrus.Fields{
 			"repo_id": repoID,
 			"error":   err,
 		}).Error("Failed to get last pipeline")
-		return nil, fmt.Errorf("failed to get last pipeline for repo %d: %w", repoID, err)
+		return nil, wrapError(err, "failed to get last pipeline for repo %d", repoID)
 	}
 
 	return pipeline, nil
 }
 
-func (c *Client) StartPipeline(repoID, pipelineNum int64, params map[string]string) (*woodpecker.Pipeline, error) {
-	c.waitForRateLimit()
+func (c *Client) StartPipeline(ctx context.Context, repoID, pipelineNum int64, params map[string]string) (*woodpecker.Pipeline, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
 	options := woodpecker.PipelineStartOptions{
 		Params: params,
 	}
-	pipeline, err := c.client.PipelineStart(repoID, pipelineNum, options)
+	pipeline, err := c.api(ctx).PipelineStart(repoID, pipelineNum, options)
 	if err != nil {
 		c.logger.WithFields(logrus.Fields{
 			"repo_id":      repoID,
 			"pipeline_num": pipelineNum,
 			"error":        err,
 		}).Error("Failed to start pipeline")
-		return nil, fmt.Errorf("failed to start pipeline %d for repo %d: %w", pipelineNum, repoID, err)
+		return nil, wrapError(err, "failed to start pipeline %d for repo %d", pipelineNum, repoID)
 	}
 
 	c.logger.WithFields(logrus.Fields{
@@ -197,16 +332,19 @@ func (c *Client) StartPipeline(repoID, pipelineNum int64, params map[string]stri
 	return pipeline, nil
 }
 
-func (c *Client) StopPipeline(repoID, pipelineNum int64) error {
-	c.waitForRateLimit()
-	err := c.client.PipelineStop(repoID, pipelineNum)
+func (c *Client) StopPipeline(ctx context.Context, repoID, pipelineNum int64) error {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return err
+	}
+	// Cancelling an already cancelled pipeline is harmless, so allow retries
+	err := c.api(withRetrySafe(ctx)).PipelineStop(repoID, pipelineNum)
 	if err != nil {
 		c.logger.WithFields(logrus.Fields{
 			"repo_id":      repoID,
 			"pipeline_num": pipelineNum,
 			"error":        err,
 		}).Error("Failed to stop pipeline")
-		return fmt.Errorf("failed to stop pipeline %d for repo %d: %w", pipelineNum, repoID, err)
+		return wrapError(err, "failed to stop pipeline %d for repo %d", pipelineNum, repoID)
 	}
 
 	c.logger.WithFields(logrus.Fields{
@@ -216,16 +354,18 @@ func (c *Client) StopPipeline(repoID, pipelineNum int64) error {
 	return nil
 }
 
-func (c *Client) ApprovePipeline(repoID, pipelineNum int64) (*woodpecker.Pipeline, error) {
-	c.waitForRateLimit()
-	pipeline, err := c.client.PipelineApprove(repoID, pipelineNum)
+func (c *Client) ApprovePipeline(ctx context.Context, repoID, pipelineNum int64) (*woodpecker.Pipeline, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	pipeline, err := c.api(ctx).PipelineApprove(repoID, pipelineNum)
 	if err != nil {
 		c.logger.WithFields(logrus.Fields{
 			"repo_id":      repoID,
 			"pipeline_num": pipelineNum,
 			"error":        err,
 		}).Error("Failed to approve pipeline")
-		return nil, fmt.Errorf("failed to approve pipeline %d for repo %d: %w", pipelineNum, repoID, err)
+		return nil, wrapError(err, "failed to approve pipeline %d for repo %d", pipelineNum, repoID)
 	}
 
 	c.logger.WithFields(logrus.Fields{
@@ -235,16 +375,18 @@ func (c *Client) ApprovePipeline(repoID, pipelineNum int64) (*woodpecker.Pipelin
 	return pipeline, nil
 }
 
-func (c *Client) DeclinePipeline(repoID, pipelineNum int64) (*woodpecker.Pipeline, error) {
-	c.waitForRateLimit()
-	pipeline, err := c.client.PipelineDecline(repoID, pipelineNum)
+func (c *Client) DeclinePipeline(ctx context.Context, repoID, pipelineNum int64) (*woodpecker.Pipeline, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	pipeline, err := c.api(ctx).PipelineDecline(repoID, pipelineNum)
 	if err != nil {
 		c.logger.WithFields(logrus.Fields{
 			"repo_id":      repoID,
 			"pipeline_num": pipelineNum,
 			"error":        err,
 		}).Error("Failed to decline pipeline")
-		return nil, fmt.Errorf("failed to decline pipeline %d for repo %d: %w", pipelineNum, repoID, err)
+		return nil, wrapError(err, "failed to decline pipeline %d for repo %d", pipelineNum, repoID)
 	}
 
 	c.logger.WithFields(logrus.Fields{
@@ -254,15 +396,17 @@ func (c *Client) DeclinePipeline(repoID, pipelineNum int64) (*woodpecker.Pipelin
 	return pipeline, nil
 }
 
-func (c *Client) CreatePipeline(repoID int64, opt *woodpecker.PipelineOptions) (*woodpecker.Pipeline, error) {
-	c.waitForRateLimit()
-	pipeline, err := c.client.PipelineCreate(repoID, opt)
+func (c *Client) CreatePipeline(ctx context.Context, repoID int64, opt *woodpecker.PipelineOptions) (*woodpecker.Pipeline, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	pipeline, err := c.api(ctx).PipelineCreate(repoID, opt)
 	if err != nil {
 		c.logger.WithFields(logrus.Fields{
 			"repo_id": repoID,
 			"error":   err,
 		}).Error("Failed to create pipeline")
-		return nil, fmt.Errorf("failed to create pipeline for repo %d: %w", repoID, err)
+		return nil, wrapError(err, "failed to create pipeline for repo %d", repoID)
 	}
 
 	c.logger.WithFields(logrus.Fields{
@@ -274,9 +418,11 @@ func (c *Client) CreatePipeline(repoID int64, opt *woodpecker.PipelineOptions) (
 }
 
 // Log methods
-func (c *Client) GetStepLogs(repoID, pipelineNum, stepID int64) ([]*woodpecker.LogEntry, error) {
-	c.waitForRateLimit()
-	logs, err := c.client.StepLogEntries(repoID, pipelineNum, stepID)
+func (c *Client) GetStepLogs(ctx context.Context, repoID, pipelineNum, stepID int64) ([]*woodpecker.LogEntry, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	logs, err := c.api(ctx).StepLogEntries(repoID, pipelineNum, stepID)
 	if err != nil {
 		c.logger.WithFields(logrus.Fields{
 			"repo_id":      repoID,
@@ -284,19 +430,21 @@ func (c *Client) GetStepLogs(repoID, pipelineNum, stepID int64) ([]*woodpecker.L
 			"step_id":      stepID,
 			"error":        err,
 		}).Error("Failed to get step logs")
-		return nil, fmt.Errorf("failed to get logs for step %d in pipeline %d for repo %d: %w", stepID, pipelineNum, repoID, err)
+		return nil, wrapError(err, "failed to get logs for step %d in pipeline %d for repo %d", stepID, pipelineNum, repoID)
 	}
 
 	return logs, nil
 }
 
 // User methods
-func (c *Client) GetCurrentUser() (*woodpecker.User, error) {
-	c.waitForRateLimit()
-	user, err := c.client.Self()
+func (c *Client) GetCurrentUser(ctx context.Context) (*woodpecker.User, error) {
+	if err := c.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	user, err := c.api(ctx).Self()
 	if err != nil {
 		c.logger.WithError(err).Error("Failed to get current user")
-		return nil, fmt.Errorf("failed to get current user: %w", err)
+		return nil, wrapError(err, "failed to get current user")
 	}
 
 	return user, nil
@@ -1,9 +1,14 @@
 package client
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
@@ -102,7 +107,7 @@ func TestGetCurrentUser_Success(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, client)
 
-	user, err := client.GetCurrentUser()
+	user, err := client.GetCurrentUser(context.Background())
 	require.NoError(t, err)
 	require.NotNil(t, user)
 	require.Equal(t, int64(1), user.ID)
@@ -135,6 +140,52 @@ func TestTestConnection_Failure(t *testing.T) {
 	require.Nil(t, client)
 }
 
+func TestGetCurrentUser_CancelledContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"id": 1, "login": "testuser"}`))
+	}))
+	defer server.Close()
+
+	client, err := New(Config{URL: server.URL, Token: "test-token"}, logrus.New())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	user, err := client.GetCurrentUser(ctx)
+	require.Error(t, err)
+	require.Nil(t, user)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestGetCurrentUser_ContextAbortsInFlightRequest(t *testing.T) {
+	var block atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if block.Load() {
+			<-r.Context().Done()
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"id": 1, "login": "testuser"}`))
+	}))
+	defer server.Close()
+
+	client, err := New(Config{URL: server.URL, Token: "test-token"}, logrus.New())
+	require.NoError(t, err)
+	block.Store(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = client.GetCurrentUser(ctx)
+	require.Error(t, err)
+	require.Less(t, time.Since(start), 5*time.Second)
+}
+
 // The following tests require knowledge of the woodpecker-go library's specific API paths.
 // They are skipped because mocking the HTTP responses correctly requires detailed knowledge
 // of the library's internal API structure. In production, these would be tested against
@@ -175,3 +226,82 @@ func TestStartPipeline_Success(t *testing.T) {
 func TestStopPipeline_Success(t *testing.T) {
 	t.Skip("Requires woodpecker-go library API path knowledge")
 }
+
+func TestHasBranch_PagesUntilFound(t *testing.T) {
+	var pages atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/user":
+			_, _ = w.Write([]byte(`{"id": 1, "login": "testuser"}`))
+		case "/api/repos/5/branches":
+			pages.Add(1)
+			if r.URL.Query().Get("page") == "1" {
+				branches := make([]string, branchPageSize)
+				for i := range branches {
+					branches[i] = fmt.Sprintf("feature-%d", i)
+				}
+				_ = json.NewEncoder(w).Encode(branches)
+				return
+			}
+			_, _ = w.Write([]byte(`["main", "release"]`))
+		case "/api/repos/8/branches":
+			branches := make([]string, branchPageSize)
+			for i := range branches {
+				branches[i] = fmt.Sprintf("feature-%s-%d", r.URL.Query().Get("page"), i)
+			}
+			_ = json.NewEncoder(w).Encode(branches)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c, err := New(Config{URL: server.URL, Token: "test-token"}, logrus.New())
+	require.NoError(t, err)
+
+	ok, err := c.HasBranch(context.Background(), 5, "release")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, int32(2), pages.Load())
+
+	ok, err = c.HasBranch(context.Background(), 5, "missing")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, err = c.HasBranch(context.Background(), 6, "main")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// Past the last page read, the branch is neither found nor missing
+	ok, err = c.HasBranch(context.Background(), 8, "main")
+	require.ErrorIs(t, err, ErrBranchUnverified)
+	require.False(t, ok)
+}
+
+func TestPipelineURL(t *testing.T) {
+	c := &Client{url: "https://ci.example.com/"}
+	require.Equal(t, "https://ci.example.com/repos/5/pipeline/12", c.PipelineURL(5, 12))
+}
+
+func TestRunCron(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/user":
+			_, _ = w.Write([]byte(`{"id": 1, "login": "testuser"}`))
+		case r.URL.Path == "/api/repos/5/cron/3" && r.Method == http.MethodPost:
+			_, _ = w.Write([]byte(`{"id": 40, "number": 12, "event": "cron", "branch": "main"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c, err := New(Config{URL: server.URL, Token: "test-token"}, logrus.New())
+	require.NoError(t, err)
+
+	pipeline, err := c.RunCron(context.Background(), 5, 3)
+	require.NoError(t, err)
+	require.Equal(t, int64(12), pipeline.Number)
+
+	_, err = c.RunCron(context.Background(), 5, 4)
+	require.ErrorIs(t, err, ErrNotFound)
+}
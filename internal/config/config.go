@@ -2,8 +2,12 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -12,21 +16,139 @@ type Config struct {
 	Woodpecker WoodpeckerConfig `mapstructure:"woodpecker"`
 	Server     ServerConfig     `mapstructure:"server"`
 	Logging    LoggingConfig    `mapstructure:"logging"`
+	Policy     PolicyConfig     `mapstructure:"policy"`
+	Redaction  RedactionConfig  `mapstructure:"redaction"`
 }
 
 type WoodpeckerConfig struct {
-	URL   string `mapstructure:"url"`
-	Token string `mapstructure:"token"`
+	URL   string      `mapstructure:"url"`
+	Token string      `mapstructure:"token"`
+	Retry RetryConfig `mapstructure:"retry"`
+}
+
+type RetryConfig struct {
+	MaxAttempts int           `mapstructure:"max_attempts"`
+	MaxDelay    time.Duration `mapstructure:"max_delay"`
 }
 
 type ServerConfig struct {
 	Name    string `mapstructure:"name"`
 	Version string `mapstructure:"version"`
+	// Transport is one of stdio, http (streamable HTTP) or sse
+	Transport string     `mapstructure:"transport"`
+	Address   string     `mapstructure:"address"`
+	BasePath  string     `mapstructure:"base_path"`
+	TLS       TLSConfig  `mapstructure:"tls"`
+	Auth      AuthConfig `mapstructure:"auth"`
+	// ReadOnly exposes only tools that do not modify Woodpecker state
+	ReadOnly     bool               `mapstructure:"read_only"`
+	Tools        ToolsConfig        `mapstructure:"tools"`
+	Confirmation ConfirmationConfig `mapstructure:"confirmation"`
+}
+
+// ConfirmationConfig makes the listed tools return a confirm_token describing the
+// action instead of executing it; the call only runs when repeated with that token
+type ConfirmationConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Tools holds glob patterns over tool names that require confirmation
+	Tools []string `mapstructure:"tools"`
+	// TTL is how long an issued confirm_token stays valid
+	TTL time.Duration `mapstructure:"ttl"`
+}
+
+// ToolsConfig holds glob patterns over tool names; deny wins over allow
+type ToolsConfig struct {
+	Allow []string `mapstructure:"allow"`
+	Deny  []string `mapstructure:"deny"`
+}
+
+// AuthConfig controls which Woodpecker token is used for callers of the http and sse transports
+type AuthConfig struct {
+	// Mode is shared (everyone uses woodpecker.token), token (callers send their own
+	// Woodpecker token as a bearer token) or api_key (callers send an API key mapped below)
+	Mode string `mapstructure:"mode"`
+	// APIKeys are required of callers in api_key mode and, when set, in shared mode,
+	// where their tokens may be left empty
+	APIKeys []APIKeyConfig `mapstructure:"api_keys"`
+	// AllowUnauthenticated lets shared mode serve http or sse on a non-loopback address
+	// without api_keys, so anyone who can reach it acts with woodpecker.token
+	AllowUnauthenticated bool `mapstructure:"allow_unauthenticated"`
+}
+
+// APIKeyConfig maps an MCP client API key to the Woodpecker token used on its behalf
+type APIKeyConfig struct {
+	Name  string `mapstructure:"name"`
+	Key   string `mapstructure:"key"`
+	Token string `mapstructure:"token"`
+}
+
+type TLSConfig struct {
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+}
+
+// Supported values for ServerConfig.Transport
+const (
+	TransportStdio = "stdio"
+	TransportHTTP  = "http"
+	TransportSSE   = "sse"
+)
+
+// Supported values for AuthConfig.Mode
+const (
+	AuthModeShared = "shared"
+	AuthModeToken  = "token"
+	AuthModeAPIKey = "api_key"
+)
+
+// RedactionConfig masks credentials in tool results before they reach the agent
+type RedactionConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Patterns are extra regular expressions whose matches are masked
+	Patterns []string `mapstructure:"patterns"`
+}
+
+// PolicyConfig restricts which repositories and branches mutating tools may act on
+type PolicyConfig struct {
+	// Default is the effect when no rule matches: allow (default) or deny
+	Default string       `mapstructure:"default"`
+	Rules   []PolicyRule `mapstructure:"rules"`
+}
+
+// PolicyRule matches a tool call when every non-empty pattern list has a match.
+// Repos accepts owner/repo globs or numeric repository IDs.
+type PolicyRule struct {
+	Effect   string   `mapstructure:"effect"`
+	Tools    []string `mapstructure:"tools"`
+	Repos    []string `mapstructure:"repos"`
+	Branches []string `mapstructure:"branches"`
 }
 
 type LoggingConfig struct {
-	Level  string `mapstructure:"level"`
-	Format string `mapstructure:"format"`
+	Level  string      `mapstructure:"level"`
+	Format string      `mapstructure:"format"`
+	Audit  AuditConfig `mapstructure:"audit"`
+}
+
+// AuditConfig enables the append-only record of every tool invocation
+type AuditConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// File is the JSONL file records are appended to; empty disables the file sink
+	File string `mapstructure:"file"`
+	// MaxSizeMB rotates the file once it would grow beyond this size (0 disables rotation)
+	MaxSizeMB int `mapstructure:"max_size_mb"`
+	// MaxBackups is how many rotated files are kept (0 keeps all)
+	MaxBackups int               `mapstructure:"max_backups"`
+	Syslog     AuditSyslogConfig `mapstructure:"syslog"`
+}
+
+// AuditSyslogConfig additionally sends audit records to syslog
+type AuditSyslogConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Network and Address select a remote syslog server, e.g. udp and host:514; empty uses the local one
+	Network string `mapstructure:"network"`
+	Address string `mapstructure:"address"`
+	Tag     string `mapstructure:"tag"`
 }
 
 // userHomeDirFunc is a variable that allows mocking os.UserHomeDir in tests
@@ -66,8 +188,20 @@ func Load() (*Config, error) {
 func setDefaults(v *viper.Viper) {
 	v.SetDefault("server.name", "woodpecker-mcp")
 	v.SetDefault("server.version", "1.0.0")
+	v.SetDefault("server.transport", TransportStdio)
+	v.SetDefault("server.address", ":8080")
+	v.SetDefault("server.auth.mode", AuthModeShared)
+	v.SetDefault("server.confirmation.tools", []string{"stop_pipeline", "approve_pipeline", "trigger_pipeline"})
+	v.SetDefault("server.confirmation.ttl", "5m")
+	v.SetDefault("woodpecker.retry.max_attempts", 3)
+	v.SetDefault("woodpecker.retry.max_delay", "10s")
+	v.SetDefault("policy.default", "allow")
+	v.SetDefault("redaction.enabled", true)
 	v.SetDefault("logging.level", "info")
 	v.SetDefault("logging.format", "text")
+	v.SetDefault("logging.audit.max_size_mb", 100)
+	v.SetDefault("logging.audit.max_backups", 5)
+	v.SetDefault("logging.audit.syslog.tag", "woodpecker-mcp")
 }
 
 func (c *Config) Validate() error {
@@ -77,9 +211,81 @@ func (c *Config) Validate() error {
 	if c.Woodpecker.Token == "" {
 		return fmt.Errorf("woodpecker token is required")
 	}
+
+	switch c.Server.Transport {
+	case "", TransportStdio, TransportHTTP, TransportSSE:
+	default:
+		return fmt.Errorf("unsupported server transport %q (expected stdio, http or sse)", c.Server.Transport)
+	}
+	if (c.Server.TLS.CertFile == "") != (c.Server.TLS.KeyFile == "") {
+		return fmt.Errorf("both server TLS cert_file and key_file must be set")
+	}
+
+	switch c.Server.Auth.Mode {
+	case "", AuthModeShared:
+		if (c.Server.Transport == TransportHTTP || c.Server.Transport == TransportSSE) &&
+			len(c.Server.Auth.APIKeys) == 0 && !c.Server.Auth.AllowUnauthenticated && !isLoopbackAddress(c.Server.Address) {
+			return fmt.Errorf("server auth mode shared would let anyone who can reach %q use woodpecker.token; "+
+				"listen on a loopback address, configure server.auth.api_keys or another auth mode, or set server.auth.allow_unauthenticated", c.Server.Address)
+		}
+	case AuthModeToken, AuthModeAPIKey:
+		if c.Server.Transport != TransportHTTP && c.Server.Transport != TransportSSE {
+			return fmt.Errorf("server auth mode %q requires the http or sse transport", c.Server.Auth.Mode)
+		}
+	default:
+		return fmt.Errorf("unsupported server auth mode %q (expected shared, token or api_key)", c.Server.Auth.Mode)
+	}
+	if c.Logging.Audit.Enabled && c.Logging.Audit.File == "" && !c.Logging.Audit.Syslog.Enabled {
+		return fmt.Errorf("audit logging requires logging.audit.file or logging.audit.syslog")
+	}
+	if c.Logging.Audit.MaxSizeMB < 0 || c.Logging.Audit.MaxBackups < 0 {
+		return fmt.Errorf("audit max_size_mb and max_backups must not be negative")
+	}
+	for _, pattern := range c.Redaction.Patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+	}
+	if c.Server.Confirmation.Enabled && c.Server.Confirmation.TTL <= 0 {
+		return fmt.Errorf("server confirmation ttl must be positive")
+	}
+	patterns := append(append([]string{}, c.Server.Tools.Allow...), c.Server.Tools.Deny...)
+	for _, pattern := range append(patterns, c.Server.Confirmation.Tools...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid server tools pattern %q: %w", pattern, err)
+		}
+	}
+	if c.Server.Auth.Mode == AuthModeAPIKey {
+		if len(c.Server.Auth.APIKeys) == 0 {
+			return fmt.Errorf("server auth mode api_key requires at least one entry in api_keys")
+		}
+		for i, key := range c.Server.Auth.APIKeys {
+			if key.Key == "" || key.Token == "" {
+				return fmt.Errorf("server auth api_keys[%d] must set both key and token", i)
+			}
+		}
+	}
+	for i, key := range c.Server.Auth.APIKeys {
+		if key.Key == "" {
+			return fmt.Errorf("server auth api_keys[%d] must set key", i)
+		}
+	}
 	return nil
 }
 
+// isLoopbackAddress reports whether a listen address only accepts local connections
+func isLoopbackAddress(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
 func GetConfigDir() (string, error) {
 	homeDir, err := userHomeDirFunc()
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -66,6 +67,10 @@ func TestLoad_MissingConfig(t *testing.T) {
 	require.Equal(t, "1.0.0", cfg.Server.Version)
 	require.Equal(t, "info", cfg.Logging.Level)
 	require.Equal(t, "text", cfg.Logging.Format)
+	require.Equal(t, "stdio", cfg.Server.Transport)
+	require.Equal(t, ":8080", cfg.Server.Address)
+	require.Equal(t, 3, cfg.Woodpecker.Retry.MaxAttempts)
+	require.Equal(t, 10*time.Second, cfg.Woodpecker.Retry.MaxDelay)
 }
 
 func TestValidate_ValidConfig(t *testing.T) {
@@ -120,6 +125,150 @@ func TestValidate_MissingURLAndToken(t *testing.T) {
 	require.Contains(t, err.Error(), "URL is required")
 }
 
+func TestValidate_UnsupportedTransport(t *testing.T) {
+	cfg := &Config{
+		Woodpecker: WoodpeckerConfig{
+			URL:   "https://woodpecker.example.com",
+			Token: "test-token",
+		},
+		Server: ServerConfig{
+			Transport: "websocket",
+		},
+	}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unsupported server transport")
+}
+
+func TestValidate_PartialTLS(t *testing.T) {
+	cfg := &Config{
+		Woodpecker: WoodpeckerConfig{
+			URL:   "https://woodpecker.example.com",
+			Token: "test-token",
+		},
+		Server: ServerConfig{
+			Transport: TransportHTTP,
+			TLS:       TLSConfig{CertFile: "/etc/ssl/cert.pem"},
+		},
+	}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cert_file and key_file")
+}
+
+func TestValidate_AuthModes(t *testing.T) {
+	cfg := &Config{
+		Woodpecker: WoodpeckerConfig{
+			URL:   "https://woodpecker.example.com",
+			Token: "test-token",
+		},
+		Server: ServerConfig{
+			Transport: TransportStdio,
+			Auth:      AuthConfig{Mode: AuthModeToken},
+		},
+	}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "requires the http or sse transport")
+
+	cfg.Server.Transport = TransportHTTP
+	require.NoError(t, cfg.Validate())
+
+	cfg.Server.Auth.Mode = AuthModeAPIKey
+	err = cfg.Validate()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "at least one entry")
+
+	cfg.Server.Auth.APIKeys = []APIKeyConfig{{Name: "alice", Key: "key"}}
+	err = cfg.Validate()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "api_keys[0]")
+
+	cfg.Server.Auth.APIKeys[0].Token = "woodpecker-token"
+	require.NoError(t, cfg.Validate())
+}
+
+func TestValidate_SharedModeOverNetwork(t *testing.T) {
+	cfg := &Config{
+		Woodpecker: WoodpeckerConfig{
+			URL:   "https://woodpecker.example.com",
+			Token: "test-token",
+		},
+		Server: ServerConfig{
+			Transport: TransportHTTP,
+			Address:   ":8080",
+			Auth:      AuthConfig{Mode: AuthModeShared},
+		},
+	}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "allow_unauthenticated")
+
+	cfg.Server.Transport = TransportSSE
+	require.Error(t, cfg.Validate())
+
+	for _, address := range []string{"127.0.0.1:8080", "localhost:8080", "[::1]:8080"} {
+		cfg.Server.Address = address
+		require.NoError(t, cfg.Validate(), address)
+	}
+
+	cfg.Server.Address = "0.0.0.0:8080"
+	cfg.Server.Auth.APIKeys = []APIKeyConfig{{Name: "ci"}}
+	err = cfg.Validate()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "api_keys[0]")
+
+	cfg.Server.Auth.APIKeys[0].Key = "ci-mcp-key"
+	require.NoError(t, cfg.Validate())
+
+	cfg.Server.Auth.APIKeys = nil
+	cfg.Server.Auth.AllowUnauthenticated = true
+	require.NoError(t, cfg.Validate())
+
+	cfg.Server.Transport = TransportStdio
+	cfg.Server.Auth.AllowUnauthenticated = false
+	require.NoError(t, cfg.Validate())
+}
+
+func TestValidate_InvalidToolPattern(t *testing.T) {
+	cfg := &Config{
+		Woodpecker: WoodpeckerConfig{
+			URL:   "https://woodpecker.example.com",
+			Token: "test-token",
+		},
+		Server: ServerConfig{
+			Tools: ToolsConfig{Deny: []string{"[start_*"}},
+		},
+	}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid server tools pattern")
+}
+
+func TestValidate_Confirmation(t *testing.T) {
+	cfg := &Config{
+		Woodpecker: WoodpeckerConfig{
+			URL:   "https://woodpecker.example.com",
+			Token: "test-token",
+		},
+		Server: ServerConfig{
+			Confirmation: ConfirmationConfig{Enabled: true, Tools: []string{"stop_pipeline"}},
+		},
+	}
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "ttl")
+
+	cfg.Server.Confirmation.TTL = time.Minute
+	require.NoError(t, cfg.Validate())
+}
+
 func TestGetConfigDir(t *testing.T) {
 	homeDir, err := os.UserHomeDir()
 	require.NoError(t, err)
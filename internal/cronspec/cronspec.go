@@ -0,0 +1,250 @@
+// Package cronspec parses the cron schedules Woodpecker accepts and computes
+// their run times: five fields (minute, hour, day of month, month, day of week),
+// the @yearly/@monthly/@weekly/@daily/@hourly descriptors, @every <duration>, and
+// an optional CRON_TZ= or TZ= prefix selecting the time zone.
+package cronspec
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSearchYears bounds the search for the next run of a schedule that matches
+// rarely or never, such as the 30th of February
+const maxSearchYears = 5
+
+// Spec is a parsed schedule
+type Spec struct {
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar record an unrestricted field, which changes how the
+	// two day fields combine
+	domStar, dowStar bool
+	// every is set for @every schedules, which run at a fixed interval
+	every    time.Duration
+	location *time.Location
+}
+
+type bounds struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteBounds = bounds{0, 59, nil}
+	hourBounds   = bounds{0, 23, nil}
+	domBounds    = bounds{1, 31, nil}
+	monthBounds  = bounds{1, 12, map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowBounds = bounds{0, 6, map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a schedule, reporting which part of it is invalid
+func Parse(schedule string) (*Spec, error) {
+	spec := &Spec{location: time.UTC}
+
+	expr := strings.TrimSpace(schedule)
+	if strings.HasPrefix(expr, "CRON_TZ=") || strings.HasPrefix(expr, "TZ=") {
+		zone, rest, _ := strings.Cut(expr, " ")
+		_, name, _ := strings.Cut(zone, "=")
+		location, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("unknown time zone %q: %w", name, err)
+		}
+		spec.location = location
+		expr = strings.TrimSpace(rest)
+	}
+
+	if expr == "" {
+		return nil, fmt.Errorf("schedule is empty")
+	}
+
+	if strings.HasPrefix(expr, "@every ") {
+		every, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid @every duration: %w", err)
+		}
+		if every < time.Second {
+			return nil, fmt.Errorf("@every duration must be at least 1s")
+		}
+		spec.every = every.Truncate(time.Second)
+		return spec, nil
+	}
+
+	if strings.HasPrefix(expr, "@") {
+		standard, ok := descriptors[strings.ToLower(expr)]
+		if !ok {
+			return nil, fmt.Errorf("unknown descriptor %q", expr)
+		}
+		expr = standard
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields (minute hour day-of-month month day-of-week), got %d", len(fields))
+	}
+
+	var err error
+	for _, f := range []struct {
+		name   string
+		value  string
+		bounds bounds
+		bits   *uint64
+	}{
+		{"minute", fields[0], minuteBounds, &spec.minute},
+		{"hour", fields[1], hourBounds, &spec.hour},
+		{"day of month", fields[2], domBounds, &spec.dom},
+		{"month", fields[3], monthBounds, &spec.month},
+		{"day of week", fields[4], dowBounds, &spec.dow},
+	} {
+		if *f.bits, err = parseField(f.value, f.bounds); err != nil {
+			return nil, fmt.Errorf("invalid %s field %q: %w", f.name, f.value, err)
+		}
+	}
+	spec.domStar = isStar(fields[2])
+	spec.dowStar = isStar(fields[4])
+
+	return spec, nil
+}
+
+func isStar(field string) bool {
+	return field == "*" || field == "?"
+}
+
+// parseField turns a comma-separated list of values, ranges and steps into a bit set
+func parseField(field string, b bounds) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		var low, high int
+		switch {
+		case rangePart == "*" || rangePart == "?":
+			low, high = b.min, b.max
+		case strings.Contains(rangePart, "-"):
+			from, to, _ := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = parseValue(from, b); err != nil {
+				return 0, err
+			}
+			if high, err = parseValue(to, b); err != nil {
+				return 0, err
+			}
+		default:
+			value, err := parseValue(rangePart, b)
+			if err != nil {
+				return 0, err
+			}
+			low, high = value, value
+			if hasStep {
+				// "5/15" means every 15 starting at 5
+				high = b.max
+			}
+		}
+		if low > high {
+			return 0, fmt.Errorf("range %d-%d is backwards", low, high)
+		}
+
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step <= 0 {
+				return 0, fmt.Errorf("step %q must be a positive number", stepPart)
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+func parseValue(s string, b bounds) (int, error) {
+	if v, ok := b.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", s)
+	}
+	if v < b.min || v > b.max {
+		return 0, fmt.Errorf("%d is outside %d-%d", v, b.min, b.max)
+	}
+	return v, nil
+}
+
+// Next returns the first run strictly after t, or the zero time if there is none
+// within the next few years
+func (s *Spec) Next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Truncate(time.Second).Add(s.every)
+	}
+
+	original := t.Location()
+	t = t.In(s.location).Truncate(time.Minute).Add(time.Minute)
+	limit := t.Year() + maxSearchYears
+
+	for t.Year() <= limit {
+		if !has(s.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.location)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.location)
+			continue
+		}
+		if !has(s.hour, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.location)
+			continue
+		}
+		if !has(s.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t.In(original)
+	}
+	return time.Time{}
+}
+
+// NextN returns up to n consecutive runs after t
+func (s *Spec) NextN(t time.Time, n int) []time.Time {
+	runs := make([]time.Time, 0, n)
+	for len(runs) < n {
+		t = s.Next(t)
+		if t.IsZero() {
+			break
+		}
+		runs = append(runs, t)
+	}
+	return runs
+}
+
+// dayMatches follows cron: when both day fields are restricted, either may match
+func (s *Spec) dayMatches(t time.Time) bool {
+	domMatch := has(s.dom, t.Day())
+	dowMatch := has(s.dow, int(t.Weekday()))
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+func has(set uint64, v int) bool {
+	return set&(1<<uint(v)) != 0
+}
@@ -0,0 +1,91 @@
+package cronspec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNext(t *testing.T) {
+	// 2026-03-06 is a Friday
+	from := time.Date(2026, time.March, 6, 12, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		schedule string
+		want     time.Time
+	}{
+		{"* * * * *", time.Date(2026, time.March, 6, 12, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, time.March, 6, 12, 45, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2026, time.March, 6, 12, 45, 0, 0, time.UTC)},
+		{"0 3 * * 1-5", time.Date(2026, time.March, 9, 3, 0, 0, 0, time.UTC)},
+		{"0 0 * * SUN", time.Date(2026, time.March, 8, 0, 0, 0, 0, time.UTC)},
+		{"0 9 1,15 * *", time.Date(2026, time.March, 15, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		// With both day fields restricted, either one matching is enough
+		{"0 0 13 * 5", time.Date(2026, time.March, 13, 0, 0, 0, 0, time.UTC)},
+		{"0 0 7 * 1", time.Date(2026, time.March, 7, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, time.March, 6, 13, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, time.March, 7, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2026, time.March, 8, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90m", time.Date(2026, time.March, 6, 14, 0, 15, 0, time.UTC)},
+		{"CRON_TZ=Europe/Zurich 0 9 * * *", time.Date(2026, time.March, 7, 8, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.schedule, func(t *testing.T) {
+			spec, err := Parse(tt.schedule)
+			require.NoError(t, err)
+			require.True(t, tt.want.Equal(spec.Next(from)), "got %s", spec.Next(from))
+		})
+	}
+}
+
+func TestNext_Impossible(t *testing.T) {
+	spec, err := Parse("0 0 30 2 *")
+	require.NoError(t, err)
+	require.True(t, spec.Next(time.Now()).IsZero())
+	require.Empty(t, spec.NextN(time.Now(), 3))
+}
+
+func TestNextN(t *testing.T) {
+	spec, err := Parse("0 */6 * * *")
+	require.NoError(t, err)
+
+	runs := spec.NextN(time.Date(2026, time.March, 6, 5, 0, 0, 0, time.UTC), 3)
+	require.Equal(t, []time.Time{
+		time.Date(2026, time.March, 6, 6, 0, 0, 0, time.UTC),
+		time.Date(2026, time.March, 6, 12, 0, 0, 0, time.UTC),
+		time.Date(2026, time.March, 6, 18, 0, 0, 0, time.UTC),
+	}, runs)
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := map[string]string{
+		"":                            "empty",
+		"* * * *":                     "expected 5 fields",
+		"0 0 * * * *":                 "expected 5 fields",
+		"60 * * * *":                  "minute",
+		"0 24 * * *":                  "hour",
+		"0 0 0 * *":                   "day of month",
+		"0 0 * 13 *":                  "month",
+		"0 0 * * 7":                   "day of week",
+		"0 0 * * mon-foo":             "not a number",
+		"10-5 * * * *":                "backwards",
+		"*/0 * * * *":                 "step",
+		"@fortnightly":                "unknown descriptor",
+		"@every soon":                 "@every",
+		"@every 10ms":                 "at least 1s",
+		"CRON_TZ=Mars/Base * * * * *": "time zone",
+	}
+
+	for schedule, want := range tests {
+		t.Run(schedule, func(t *testing.T) {
+			_, err := Parse(schedule)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), want)
+		})
+	}
+}
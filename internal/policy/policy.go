@@ -0,0 +1,198 @@
+package policy
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/config"
+)
+
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+// Target describes the repository and branch a mutating tool call acts on
+type Target struct {
+	Tool     string
+	RepoID   int64
+	RepoName string
+	// Branch is empty when it could not be determined
+	Branch string
+	// Scope replaces the repository for calls on an organization or the whole
+	// server, e.g. "org acme" or "global"
+	Scope string
+}
+
+// Decision is the outcome of evaluating a Target
+type Decision struct {
+	Allowed bool
+	// Rule is the index of the matching rule, or -1 when the default applied
+	Rule   int
+	Reason string
+}
+
+type rule struct {
+	effect   string
+	tools    []string
+	repos    []string
+	branches []string
+}
+
+// Policy restricts which repositories and branches mutating tools may act on.
+// Rules are evaluated in order and the first match decides.
+type Policy struct {
+	rules         []rule
+	defaultEffect string
+}
+
+// New validates cfg and builds a Policy from it
+func New(cfg config.PolicyConfig) (*Policy, error) {
+	p := &Policy{defaultEffect: cfg.Default}
+	if p.defaultEffect == "" {
+		p.defaultEffect = EffectAllow
+	}
+	if p.defaultEffect != EffectAllow && p.defaultEffect != EffectDeny {
+		return nil, fmt.Errorf("policy default must be allow or deny, got %q", cfg.Default)
+	}
+
+	for i, r := range cfg.Rules {
+		if r.Effect != EffectAllow && r.Effect != EffectDeny {
+			return nil, fmt.Errorf("policy rules[%d]: effect must be allow or deny, got %q", i, r.Effect)
+		}
+		for _, pattern := range append(append(append([]string{}, r.Tools...), r.Repos...), r.Branches...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("policy rules[%d]: invalid pattern %q: %w", i, pattern, err)
+			}
+		}
+		p.rules = append(p.rules, rule{
+			effect:   r.Effect,
+			tools:    r.Tools,
+			repos:    r.Repos,
+			branches: r.Branches,
+		})
+	}
+
+	return p, nil
+}
+
+// Applies reports whether any rule mentions tool or the default denies it, i.e.
+// whether the caller needs to resolve a Target at all
+func (p *Policy) Applies(tool string) bool {
+	if p.defaultEffect == EffectDeny {
+		return true
+	}
+	for _, r := range p.rules {
+		if matchesOrEmpty(r.tools, tool) {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsBranch reports whether evaluating tool may depend on the target branch
+func (p *Policy) NeedsBranch(tool string) bool {
+	for _, r := range p.rules {
+		if len(r.branches) > 0 && matchesOrEmpty(r.tools, tool) {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate returns the decision of the first rule matching t, or the default
+func (p *Policy) Evaluate(t Target) Decision {
+	for i, r := range p.rules {
+		if !r.matches(t) {
+			continue
+		}
+		if r.effect == EffectAllow {
+			return Decision{Allowed: true, Rule: i}
+		}
+		return Decision{
+			Allowed: false,
+			Rule:    i,
+			Reason:  fmt.Sprintf("policy rule %d denies %s on %s", i, t.Tool, describe(t)),
+		}
+	}
+
+	if p.defaultEffect == EffectAllow {
+		return Decision{Allowed: true, Rule: -1}
+	}
+	return Decision{
+		Allowed: false,
+		Rule:    -1,
+		Reason:  fmt.Sprintf("no policy rule allows %s on %s", t.Tool, describe(t)),
+	}
+}
+
+func (r rule) matches(t Target) bool {
+	if !matchesOrEmpty(r.tools, t.Tool) {
+		return false
+	}
+	if len(r.repos) > 0 {
+		if t.Scope != "" {
+			// Nothing repository-wide can satisfy a repository pattern, so like an
+			// unknown branch it only matches deny rules
+			return r.effect == EffectDeny
+		}
+		if !matchesRepo(r.repos, t) {
+			return false
+		}
+	}
+	if len(r.branches) > 0 {
+		if t.Branch == "" {
+			// An unknown branch only matches deny rules so that the policy fails closed
+			return r.effect == EffectDeny
+		}
+		if !matchesAny(r.branches, t.Branch) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesRepo matches numeric patterns against the repo ID and everything else
+// against the owner/repo full name
+func matchesRepo(patterns []string, t Target) bool {
+	for _, pattern := range patterns {
+		if id, err := strconv.ParseInt(pattern, 10, 64); err == nil {
+			if id == t.RepoID {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(pattern, t.RepoName); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesOrEmpty(patterns []string, name string) bool {
+	return len(patterns) == 0 || matchesAny(patterns, name)
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func describe(t Target) string {
+	if t.Scope != "" {
+		return t.Scope
+	}
+	target := t.RepoName
+	if target == "" {
+		target = fmt.Sprintf("repo %d", t.RepoID)
+	}
+	if t.Branch != "" {
+		target += " branch " + t.Branch
+	}
+	return target
+}
@@ -0,0 +1,115 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/config"
+)
+
+func TestNew_RejectsInvalidConfig(t *testing.T) {
+	_, err := New(config.PolicyConfig{Default: "maybe"})
+	require.Error(t, err)
+
+	_, err = New(config.PolicyConfig{Rules: []config.PolicyRule{{Effect: "block"}}})
+	require.Error(t, err)
+
+	_, err = New(config.PolicyConfig{Rules: []config.PolicyRule{{Effect: EffectDeny, Repos: []string{"org/["}}}})
+	require.Error(t, err)
+}
+
+func TestEvaluate_DefaultAllowsWithoutRules(t *testing.T) {
+	p, err := New(config.PolicyConfig{})
+	require.NoError(t, err)
+
+	require.False(t, p.Applies("trigger_pipeline"))
+	require.True(t, p.Evaluate(Target{Tool: "trigger_pipeline", RepoID: 1, RepoName: "org/app"}).Allowed)
+}
+
+func TestEvaluate_FirstMatchWins(t *testing.T) {
+	p, err := New(config.PolicyConfig{
+		Default: EffectDeny,
+		Rules: []config.PolicyRule{
+			{Effect: EffectDeny, Tools: []string{"stop_pipeline"}, Repos: []string{"org/prod-*"}},
+			{Effect: EffectAllow, Repos: []string{"org/*", "42"}},
+		},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		target  Target
+		allowed bool
+		rule    int
+	}{
+		{"denied by first rule", Target{Tool: "stop_pipeline", RepoName: "org/prod-api"}, false, 0},
+		{"other tool falls through", Target{Tool: "start_pipeline", RepoName: "org/prod-api"}, true, 1},
+		{"allowed by repo ID", Target{Tool: "stop_pipeline", RepoID: 42, RepoName: "other/app"}, true, 1},
+		{"glob does not cross owner", Target{Tool: "start_pipeline", RepoName: "org2/app"}, false, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := p.Evaluate(tt.target)
+			require.Equal(t, tt.allowed, decision.Allowed)
+			require.Equal(t, tt.rule, decision.Rule)
+			if !tt.allowed {
+				require.NotEmpty(t, decision.Reason)
+			}
+		})
+	}
+}
+
+func TestEvaluate_Branches(t *testing.T) {
+	p, err := New(config.PolicyConfig{
+		Rules: []config.PolicyRule{
+			{Effect: EffectDeny, Tools: []string{"trigger_pipeline"}, Branches: []string{"main", "release/*"}},
+		},
+	})
+	require.NoError(t, err)
+
+	require.True(t, p.NeedsBranch("trigger_pipeline"))
+	require.False(t, p.NeedsBranch("stop_pipeline"))
+
+	require.False(t, p.Evaluate(Target{Tool: "trigger_pipeline", RepoName: "org/app", Branch: "release/1.2"}).Allowed)
+	require.True(t, p.Evaluate(Target{Tool: "trigger_pipeline", RepoName: "org/app", Branch: "feature/x"}).Allowed)
+
+	// An unknown branch must not slip past a deny rule
+	require.False(t, p.Evaluate(Target{Tool: "trigger_pipeline", RepoName: "org/app"}).Allowed)
+}
+
+func TestEvaluate_UnknownBranchDoesNotMatchAllowRule(t *testing.T) {
+	p, err := New(config.PolicyConfig{
+		Default: EffectDeny,
+		Rules: []config.PolicyRule{
+			{Effect: EffectAllow, Branches: []string{"feature/*"}},
+		},
+	})
+	require.NoError(t, err)
+
+	require.True(t, p.Evaluate(Target{Tool: "start_pipeline", RepoID: 1, Branch: "feature/x"}).Allowed)
+	require.False(t, p.Evaluate(Target{Tool: "start_pipeline", RepoID: 1}).Allowed)
+}
+
+func TestEvaluate_ScopeOnlyMatchesRepoRulesThatDeny(t *testing.T) {
+	p, err := New(config.PolicyConfig{
+		Default: EffectDeny,
+		Rules: []config.PolicyRule{
+			{Effect: EffectAllow, Repos: []string{"acme/*"}},
+			{Effect: EffectAllow, Tools: []string{"list_*"}},
+		},
+	})
+	require.NoError(t, err)
+
+	require.True(t, p.Evaluate(Target{Tool: "create_secret", RepoName: "acme/api"}).Allowed)
+	decision := p.Evaluate(Target{Tool: "create_secret", Scope: "org acme"})
+	require.False(t, decision.Allowed)
+	require.Contains(t, decision.Reason, "org acme")
+
+	p, err = New(config.PolicyConfig{
+		Rules: []config.PolicyRule{{Effect: EffectDeny, Repos: []string{"acme/prod-*"}}},
+	})
+	require.NoError(t, err)
+	require.False(t, p.Evaluate(Target{Tool: "delete_secret", Scope: "global"}).Allowed)
+}
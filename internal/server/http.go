@@ -0,0 +1,190 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sirupsen/logrus"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/config"
+)
+
+const (
+	shutdownTimeout  = 10 * time.Second
+	readinessTimeout = 5 * time.Second
+)
+
+// serveHTTP runs the streamable HTTP or SSE transport until ctx is cancelled,
+// then drains in-flight requests before returning
+func (s *MCPServer) serveHTTP(ctx context.Context) error {
+	basePath := normalizeBasePath(s.config.Server.BasePath)
+
+	httpServer := &http.Server{
+		Addr:              s.config.Server.Address,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(basePath+"/healthz", s.handleHealth)
+	mux.HandleFunc(basePath+"/readyz", s.handleReady)
+
+	// shutdown closes transport sessions before the listener is drained
+	var shutdown func(context.Context) error
+
+	switch s.config.Server.Transport {
+	case config.TransportSSE:
+		sseServer := server.NewSSEServer(s.server,
+			server.WithStaticBasePath(basePath),
+			server.WithHTTPServer(httpServer),
+			server.WithKeepAlive(true),
+		)
+		mux.Handle(sseServer.CompleteSsePath(), s.authenticate(sseServer))
+		mux.Handle(sseServer.CompleteMessagePath(), s.authenticate(sseServer))
+		shutdown = sseServer.Shutdown
+		s.logger.WithField("endpoint", sseServer.CompleteSsePath()).Info("Serving MCP over SSE")
+	default:
+		endpoint := basePath + "/mcp"
+		streamableServer := server.NewStreamableHTTPServer(s.server,
+			server.WithStreamableHTTPServer(httpServer),
+		)
+		mux.Handle(endpoint, s.authenticate(streamableServer))
+		shutdown = streamableServer.Shutdown
+		s.logger.WithField("endpoint", endpoint).Info("Serving MCP over streamable HTTP")
+	}
+
+	httpServer.Handler = mux
+
+	errCh := make(chan error, 1)
+	go func() {
+		s.logger.WithFields(logrus.Fields{
+			"address": httpServer.Addr,
+			"tls":     s.config.Server.TLS.CertFile != "",
+		}).Info("Listening for MCP connections")
+
+		var err error
+		if s.config.Server.TLS.CertFile != "" {
+			err = httpServer.ListenAndServeTLS(s.config.Server.TLS.CertFile, s.config.Server.TLS.KeyFile)
+		} else {
+			err = httpServer.ListenAndServe()
+		}
+		errCh <- err
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return fmt.Errorf("http server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	s.logger.Info("Shutting down MCP server...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down http server: %w", err)
+	}
+	return nil
+}
+
+// authenticate maps the caller's bearer credential to a Woodpecker token according to
+// server.auth.mode and stores it in the request context for the tool handlers. Shared
+// mode only checks the credential against api_keys, if any, and stores no token.
+func (s *MCPServer) authenticate(next http.Handler) http.Handler {
+	mode := s.config.Server.Auth.Mode
+	shared := mode != config.AuthModeToken && mode != config.AuthModeAPIKey
+	if shared && len(s.config.Server.Auth.APIKeys) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		credential := bearerToken(r)
+		if credential == "" {
+			unauthorized(w, "missing bearer token")
+			return
+		}
+
+		token := credential
+		if mode != config.AuthModeToken {
+			key, ok := s.lookupAPIKey(credential)
+			if !ok {
+				s.logger.WithField("remote_addr", r.RemoteAddr).Warn("Rejected unknown API key")
+				unauthorized(w, "invalid API key")
+				return
+			}
+			token = key.Token
+		}
+		if shared {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(client.WithToken(r.Context(), token)))
+	})
+}
+
+// lookupAPIKey finds the configured key matching credential in constant time per entry
+func (s *MCPServer) lookupAPIKey(credential string) (config.APIKeyConfig, bool) {
+	for _, key := range s.config.Server.Auth.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(key.Key), []byte(credential)) == 1 {
+			return key, true
+		}
+	}
+	return config.APIKeyConfig{}, false
+}
+
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if len(header) > len("Bearer ") && strings.EqualFold(header[:len("Bearer ")], "Bearer ") {
+		return strings.TrimSpace(header[len("Bearer "):])
+	}
+	return ""
+}
+
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="woodpecker-mcp"`)
+	http.Error(w, message, http.StatusUnauthorized)
+}
+
+// handleHealth reports liveness; it succeeds as long as the process can serve requests
+func (s *MCPServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ok\n"))
+}
+
+// handleReady reports readiness; it fails while the Woodpecker server is unreachable
+func (s *MCPServer) handleReady(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if err := s.client.TestConnection(ctx); err != nil {
+		s.logger.WithError(err).Warn("Readiness check failed")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("woodpecker unavailable\n"))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ready\n"))
+}
+
+// normalizeBasePath returns "" for the root or a path with a leading and no trailing slash
+func normalizeBasePath(basePath string) string {
+	basePath = strings.Trim(basePath, "/")
+	if basePath == "" {
+		return ""
+	}
+	return path.Clean("/" + basePath)
+}
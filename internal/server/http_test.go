@@ -0,0 +1,113 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/config"
+)
+
+// newTestServer returns an MCPServer backed by a fake Woodpecker API whose
+// /api/user endpoint fails while down is set
+func newTestServer(t *testing.T, down *atomic.Bool) *MCPServer {
+	t.Helper()
+
+	woodpecker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": 1, "login": "testuser"}`))
+	}))
+	t.Cleanup(woodpecker.Close)
+
+	logger := logrus.New()
+	wclient, err := client.New(client.Config{URL: woodpecker.URL, Token: "test-token"}, logger)
+	require.NoError(t, err)
+
+	cfg := &config.Config{Server: config.ServerConfig{Name: "test", Version: "1.0.0", Transport: config.TransportHTTP}}
+	srv, err := NewMCPServer(cfg, wclient, logger)
+	require.NoError(t, err)
+	return srv
+}
+
+func TestHandleHealth(t *testing.T) {
+	var down atomic.Bool
+	srv := newTestServer(t, &down)
+
+	rec := httptest.NewRecorder()
+	srv.handleHealth(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestHandleReady(t *testing.T) {
+	var down atomic.Bool
+	srv := newTestServer(t, &down)
+
+	rec := httptest.NewRecorder()
+	srv.handleReady(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	down.Store(true)
+	rec = httptest.NewRecorder()
+	srv.handleReady(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestNormalizeBasePath(t *testing.T) {
+	require.Equal(t, "", normalizeBasePath(""))
+	require.Equal(t, "", normalizeBasePath("/"))
+	require.Equal(t, "/woodpecker", normalizeBasePath("woodpecker"))
+	require.Equal(t, "/woodpecker/mcp", normalizeBasePath("/woodpecker/mcp/"))
+}
+
+func TestAuthenticate(t *testing.T) {
+	var down atomic.Bool
+	srv := newTestServer(t, &down)
+
+	var gotToken string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotToken, _ = client.TokenFromContext(r.Context())
+	})
+
+	call := func(authorization string) int {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		srv.authenticate(next).ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Shared mode passes requests through untouched
+	require.Equal(t, http.StatusOK, call(""))
+	require.Equal(t, "", gotToken)
+
+	// With api_keys, shared mode checks the key but keeps using the shared token
+	srv.config.Server.Auth.APIKeys = []config.APIKeyConfig{{Name: "ci", Key: "ci-mcp-key"}}
+	require.Equal(t, http.StatusUnauthorized, call(""))
+	require.Equal(t, http.StatusUnauthorized, call("Bearer unknown-key"))
+	require.Equal(t, http.StatusOK, call("Bearer ci-mcp-key"))
+	require.Equal(t, "", gotToken)
+
+	srv.config.Server.Auth.Mode = config.AuthModeToken
+	srv.config.Server.Auth.APIKeys = nil
+	require.Equal(t, http.StatusUnauthorized, call(""))
+	require.Equal(t, http.StatusOK, call("Bearer alice-woodpecker-token"))
+	require.Equal(t, "alice-woodpecker-token", gotToken)
+
+	srv.config.Server.Auth.Mode = config.AuthModeAPIKey
+	srv.config.Server.Auth.APIKeys = []config.APIKeyConfig{{Name: "bob", Key: "bob-mcp-key", Token: "bob-woodpecker-token"}}
+	require.Equal(t, http.StatusUnauthorized, call("Bearer unknown-key"))
+	require.Equal(t, http.StatusOK, call("bearer bob-mcp-key"))
+	require.Equal(t, "bob-woodpecker-token", gotToken)
+}
@@ -1,13 +1,17 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"os"
 
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/sirupsen/logrus"
 
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/audit"
 	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
 	"github.com/denysvitali/woodpecker-ci-mcp/internal/config"
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/policy"
 	"github.com/denysvitali/woodpecker-ci-mcp/tools"
 )
 
@@ -16,6 +20,7 @@ type MCPServer struct {
 	client *client.Client
 	logger *logrus.Logger
 	config *config.Config
+	audit  *audit.Logger
 }
 
 func NewMCPServer(cfg *config.Config, wclient *client.Client, logger *logrus.Logger) (*MCPServer, error) {
@@ -23,6 +28,7 @@ func NewMCPServer(cfg *config.Config, wclient *client.Client, logger *logrus.Log
 	mcpServer := server.NewMCPServer(
 		cfg.Server.Name,
 		cfg.Server.Version,
+		server.WithLogging(),
 	)
 
 	mcpSrv := &MCPServer{
@@ -45,16 +51,77 @@ func (s *MCPServer) registerTools() error {
 	// Create tool manager
 	toolManager := tools.NewToolManager(s.client, s.logger)
 
+	toolManager.SetToolFilter(tools.ToolFilter{
+		ReadOnly: s.config.Server.ReadOnly,
+		Allow:    s.config.Server.Tools.Allow,
+		Deny:     s.config.Server.Tools.Deny,
+	})
+
+	accessPolicy, err := policy.New(s.config.Policy)
+	if err != nil {
+		return fmt.Errorf("invalid policy: %w", err)
+	}
+	toolManager.SetPolicy(accessPolicy)
+
+	auditLogger, err := audit.New(s.config.Logging.Audit)
+	if err != nil {
+		return err
+	}
+	s.audit = auditLogger
+	toolManager.SetAuditLogger(auditLogger)
+
+	if s.config.Redaction.Enabled {
+		redactor, err := tools.NewRedactor(s.config.Redaction.Patterns)
+		if err != nil {
+			return err
+		}
+		toolManager.SetRedactor(redactor)
+	}
+
+	if s.config.Server.Confirmation.Enabled {
+		toolManager.SetConfirmation(s.config.Server.Confirmation.Tools, s.config.Server.Confirmation.TTL)
+	}
+
+	// Outside shared mode every HTTP caller gets a client built from their own token
+	switch s.config.Server.Auth.Mode {
+	case config.AuthModeToken, config.AuthModeAPIKey:
+		pool := client.NewPool(client.Config{
+			URL:              s.config.Woodpecker.URL,
+			RetryMaxAttempts: s.config.Woodpecker.Retry.MaxAttempts,
+			RetryMaxDelay:    s.config.Woodpecker.Retry.MaxDelay,
+		}, s.logger)
+		toolManager.SetClientResolver(func(ctx context.Context) (*client.Client, error) {
+			token, _ := client.TokenFromContext(ctx)
+			return pool.Get(ctx, token)
+		})
+	}
+
 	// Register tool handlers
 	serverTools := toolManager.GetServerTools()
 	s.server.AddTools(serverTools...)
-	s.logger.WithField("tool_count", len(serverTools)).Info("Registered MCP tools")
+	s.logger.WithFields(logrus.Fields{
+		"tool_count": len(serverTools),
+		"read_only":  s.config.Server.ReadOnly,
+	}).Info("Registered MCP tools")
 	return nil
 }
 
-func (s *MCPServer) Serve() error {
-	s.logger.Info("Starting MCP server...")
-	return server.ServeStdio(s.server)
+// Serve runs the configured transport until ctx is cancelled or the transport fails
+func (s *MCPServer) Serve(ctx context.Context) error {
+	s.logger.WithField("transport", s.config.Server.Transport).Info("Starting MCP server...")
+
+	switch s.config.Server.Transport {
+	case config.TransportHTTP, config.TransportSSE:
+		return s.serveHTTP(ctx)
+	default:
+		stdioServer := server.NewStdioServer(s.server)
+		return stdioServer.Listen(ctx, os.Stdin, os.Stdout)
+	}
+}
+
+// Close releases resources held by the server, such as the audit log
+func (s *MCPServer) Close() error {
+	return s.audit.Close()
 }
 
 func (s *MCPServer) GetToolsInfo() []ToolInfo {
@@ -69,6 +136,21 @@ func (s *MCPServer) GetToolsInfo() []ToolInfo {
 			Description: "Get the status of a specific pipeline",
 			Category:    "Pipeline Management",
 		},
+		{
+			Name:        "get_pipeline_steps",
+			Description: "Get the workflow and step tree of a pipeline",
+			Category:    "Pipeline Management",
+		},
+		{
+			Name:        "diagnose_pipeline",
+			Description: "Explain why a pipeline failed with log evidence",
+			Category:    "Pipeline Management",
+		},
+		{
+			Name:        "wait_for_pipeline",
+			Description: "Wait for a pipeline to finish",
+			Category:    "Pipeline Management",
+		},
 		{
 			Name:        "start_pipeline",
 			Description: "Start (restart) a specific pipeline",
@@ -84,6 +166,11 @@ func (s *MCPServer) GetToolsInfo() []ToolInfo {
 			Description: "Approve a pending pipeline",
 			Category:    "Pipeline Management",
 		},
+		{
+			Name:        "trigger_pipeline",
+			Description: "Trigger a manual pipeline for a branch",
+			Category:    "Pipeline Management",
+		},
 		{
 			Name:        "list_repositories",
 			Description: "List all repositories accessible to the authenticated user",
@@ -99,6 +186,116 @@ func (s *MCPServer) GetToolsInfo() []ToolInfo {
 			Description: "Get logs for a specific pipeline step",
 			Category:    "Log Management",
 		},
+		{
+			Name:        "search_logs",
+			Description: "Search pipeline logs with a regular expression",
+			Category:    "Log Management",
+		},
+		{
+			Name:        "follow_logs",
+			Description: "Follow a running step's log until it finishes",
+			Category:    "Log Management",
+		},
+		{
+			Name:        "list_secrets",
+			Description: "List the repository, organization and global secrets a repository sees, without their values",
+			Category:    "Secret Management",
+		},
+		{
+			Name:        "create_secret",
+			Description: "Create a repository, organization or global secret",
+			Category:    "Secret Management",
+		},
+		{
+			Name:        "update_secret",
+			Description: "Update a repository, organization or global secret",
+			Category:    "Secret Management",
+		},
+		{
+			Name:        "delete_secret",
+			Description: "Delete a repository, organization or global secret",
+			Category:    "Secret Management",
+		},
+		{
+			Name:        "list_registries",
+			Description: "List the repository and organization registry credentials a repository sees, without their passwords",
+			Category:    "Registry Credentials",
+		},
+		{
+			Name:        "create_registry",
+			Description: "Add repository or organization container registry credentials",
+			Category:    "Registry Credentials",
+		},
+		{
+			Name:        "update_registry",
+			Description: "Replace repository or organization container registry credentials",
+			Category:    "Registry Credentials",
+		},
+		{
+			Name:        "delete_registry",
+			Description: "Remove repository or organization container registry credentials",
+			Category:    "Registry Credentials",
+		},
+		{
+			Name:        "check_registry_images",
+			Description: "Check pipeline images against configured registry credentials",
+			Category:    "Registry Credentials",
+		},
+		{
+			Name:        "list_crons",
+			Description: "List cron jobs with their next run times",
+			Category:    "Cron Jobs",
+		},
+		{
+			Name:        "get_cron",
+			Description: "Get a cron job with its next run times",
+			Category:    "Cron Jobs",
+		},
+		{
+			Name:        "create_cron",
+			Description: "Create a cron job with a validated schedule",
+			Category:    "Cron Jobs",
+		},
+		{
+			Name:        "update_cron",
+			Description: "Update a cron job's name, schedule or branch",
+			Category:    "Cron Jobs",
+		},
+		{
+			Name:        "delete_cron",
+			Description: "Delete a cron job",
+			Category:    "Cron Jobs",
+		},
+		{
+			Name:        "run_cron_now",
+			Description: "Run a cron job's pipeline immediately",
+			Category:    "Cron Jobs",
+		},
+		{
+			Name:        "list_agents",
+			Description: "List agents with their labels, capacity and last contact",
+			Category:    "Agents & Queue",
+		},
+		{
+			Name:        "get_agent",
+			Description: "Get an agent with its running workflows",
+			Category:    "Agents & Queue",
+		},
+		{
+			Name:        "get_queue_info",
+			Description: "Show pending and running workflows in the queue",
+			Category:    "Agents & Queue",
+		},
+		{
+			Name:        "explain_pending_workflow",
+			Description: "Explain why a pending workflow has no agent",
+			Category:    "Agents & Queue",
+		},
+		{
+			Name:        "lint_config",
+			Description: "Lint a local Woodpecker CI pipeline configuration file",
+			Category:    "Configuration",
+		},
 	}
 }
 
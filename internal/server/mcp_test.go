@@ -0,0 +1,23 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/tools"
+)
+
+func TestGetToolsInfo_MatchesTools(t *testing.T) {
+	var want []string
+	for _, st := range tools.NewToolManager(nil, logrus.New()).GetServerTools() {
+		want = append(want, st.Tool.Name)
+	}
+
+	var got []string
+	for _, info := range (&MCPServer{}).GetToolsInfo() {
+		got = append(got, info.Name)
+	}
+	require.ElementsMatch(t, want, got)
+}
@@ -0,0 +1,618 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+)
+
+const (
+	// agentOnlineWindow is how recently an agent must have contacted the server to
+	// count as online; connected agents poll well within it
+	agentOnlineWindow = 5 * time.Minute
+	defaultQueueLimit = 50
+	maxQueueLimit     = 500
+	// maxQueueRepos and maxQueuePipelines bound the lookups get_queue_info makes to
+	// find when each task was queued
+	maxQueueRepos     = 10
+	maxQueuePipelines = 20
+	// queuePipelinePage is how many recent pipelines of a repository are searched
+	queuePipelinePage = 25
+)
+
+// Where a workflow was found in the queue
+const (
+	queuePending       = "pending"
+	queueWaitingOnDeps = "waiting_on_deps"
+	queueRunning       = "running"
+	queueAbsent        = "absent"
+)
+
+// agentView is an agent as returned by the agent tools. The agent's token is
+// deliberately left out.
+type agentView struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Platform string `json:"platform"`
+	Backend  string `json:"backend"`
+	Version  string `json:"version"`
+	Capacity int32  `json:"capacity"`
+	// Scope is global or the organization the agent is limited to
+	Scope string `json:"scope"`
+	// Labels are the labels the agent accepts workflows by, including the ones
+	// Woodpecker derives from its platform, backend and hostname
+	Labels                map[string]string `json:"labels"`
+	Paused                bool              `json:"paused"`
+	Online                bool              `json:"online"`
+	LastContact           string            `json:"last_contact,omitempty"`
+	LastContactSecondsAgo int64             `json:"last_contact_seconds_ago,omitempty"`
+	LastWork              string            `json:"last_work,omitempty"`
+	RunningTasks          *int              `json:"running_tasks,omitempty"`
+}
+
+func newAgentView(a *woodpecker.Agent, now time.Time) agentView {
+	view := agentView{
+		ID:          a.ID,
+		Name:        a.Name,
+		Platform:    a.Platform,
+		Backend:     a.Backend,
+		Version:     a.Version,
+		Capacity:    a.Capacity,
+		Scope:       "global",
+		Labels:      agentLabels(a),
+		Paused:      a.NoSchedule,
+		Online:      agentOnline(a, now),
+		LastContact: formatUnix(a.LastContact),
+		LastWork:    formatUnix(a.LastWork),
+	}
+	if a.OrgID > 0 {
+		view.Scope = fmt.Sprintf("org %d", a.OrgID)
+	}
+	if a.LastContact > 0 {
+		view.LastContactSecondsAgo = int64(now.Sub(time.Unix(a.LastContact, 0)).Seconds())
+	}
+	return view
+}
+
+func agentOnline(a *woodpecker.Agent, now time.Time) bool {
+	return a.LastContact > 0 && now.Sub(time.Unix(a.LastContact, 0)) <= agentOnlineWindow
+}
+
+// agentLabels returns the labels Woodpecker filters tasks by for an agent: its
+// platform, backend and hostname, a wildcard repo, its organization or a wildcard
+// for global agents, and its custom labels
+func agentLabels(a *woodpecker.Agent) map[string]string {
+	labels := map[string]string{"repo": "*", "org-id": "*"}
+	if a.Platform != "" {
+		labels["platform"] = a.Platform
+	}
+	if a.Backend != "" {
+		labels["backend"] = a.Backend
+	}
+	if a.Name != "" {
+		labels["hostname"] = a.Name
+	}
+	if a.OrgID > 0 {
+		labels["org-id"] = strconv.FormatInt(a.OrgID, 10)
+	}
+	for k, v := range a.CustomLabels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// labelMismatches explains why an agent with agentLabels would not take a task
+// with taskLabels, following Woodpecker's filter: every non-empty task label must
+// exist on the agent with the same value or the wildcard *. An agent label
+// prefixed with ! is required: the agent only takes tasks that have it.
+func labelMismatches(taskLabels, agentLabels map[string]string) []string {
+	var reasons []string
+	for _, key := range sortedKeys(taskLabels) {
+		want := taskLabels[key]
+		if want == "" {
+			continue
+		}
+		have, ok := agentLabels[key]
+		if !ok {
+			have, ok = agentLabels["!"+key]
+		}
+		switch {
+		case !ok:
+			reasons = append(reasons, fmt.Sprintf("agent has no %s label; the workflow needs %s=%s", key, key, want))
+		case have != "*" && have != want:
+			reasons = append(reasons, fmt.Sprintf("agent has %s=%s; the workflow needs %s=%s", key, have, key, want))
+		}
+	}
+	for _, key := range sortedKeys(agentLabels) {
+		required, ok := strings.CutPrefix(key, "!")
+		if !ok {
+			continue
+		}
+		value := agentLabels[key]
+		if have, ok := taskLabels[required]; !ok || (value != "*" && have != value) {
+			reasons = append(reasons, fmt.Sprintf("agent only takes workflows labelled %s=%s", required, value))
+		}
+	}
+	return reasons
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// runningByAgent counts the tasks each agent is running
+func runningByAgent(info *woodpecker.Info) map[int64]int {
+	running := map[int64]int{}
+	for _, task := range info.Running {
+		running[task.AgentID]++
+	}
+	return running
+}
+
+// agentMatch is one agent judged against a pending workflow
+type agentMatch struct {
+	ID          int64    `json:"id"`
+	Name        string   `json:"name"`
+	LabelsMatch bool     `json:"labels_match"`
+	Available   bool     `json:"available"`
+	Reasons     []string `json:"reasons,omitempty"`
+}
+
+// matchAgents judges every agent against a task's labels and, for agents whose
+// labels match, whether they can take it now
+func matchAgents(task woodpecker.Task, agents []*woodpecker.Agent, running map[int64]int, now time.Time) []agentMatch {
+	matches := make([]agentMatch, 0, len(agents))
+	for _, a := range agents {
+		match := agentMatch{ID: a.ID, Name: a.Name}
+		match.Reasons = labelMismatches(task.Labels, agentLabels(a))
+		match.LabelsMatch = len(match.Reasons) == 0
+
+		if match.LabelsMatch {
+			switch {
+			case a.NoSchedule:
+				match.Reasons = append(match.Reasons, "agent is paused and takes no new workflows")
+			case !agentOnline(a, now):
+				match.Reasons = append(match.Reasons, "agent has not contacted the server recently and is probably offline")
+			case a.Capacity > 0 && running[a.ID] >= int(a.Capacity):
+				match.Reasons = append(match.Reasons, fmt.Sprintf("agent is busy running %d of %d workflows", running[a.ID], a.Capacity))
+			default:
+				match.Available = true
+			}
+		}
+		matches = append(matches, match)
+	}
+	return matches
+}
+
+// pendingVerdict sums up why a queued task has not started, from the agents judged by matchAgents
+func pendingVerdict(matches []agentMatch, paused bool) string {
+	if paused {
+		return "The queue is paused, so no workflow is handed to agents until an admin resumes it."
+	}
+	if len(matches) == 0 {
+		return "No agents are registered with the server."
+	}
+
+	var labelled, available int
+	for _, m := range matches {
+		if m.LabelsMatch {
+			labelled++
+		}
+		if m.Available {
+			available++
+		}
+	}
+
+	switch {
+	case labelled == 0:
+		return "No agent's labels match the workflow's labels, so it will wait until such an agent connects. Compare the workflow's labels with each agent's reasons, and fix the workflow's labels or platform or start an agent with matching labels."
+	case available == 0:
+		return fmt.Sprintf("%d agent(s) match the workflow's labels, but none can take it now because they are paused, offline or at capacity; see their reasons.", labelled)
+	}
+	return fmt.Sprintf("%d matching agent(s) have free capacity, so the workflow should start on an agent's next poll; if it does not, check the agents' logs.", available)
+}
+
+// taskView is a queued or running workflow as returned by get_queue_info
+type taskView struct {
+	WorkflowID     string            `json:"workflow_id"`
+	Repo           string            `json:"repo,omitempty"`
+	PipelineNumber int64             `json:"pipeline_number,omitempty"`
+	Workflow       string            `json:"workflow,omitempty"`
+	Labels         map[string]string `json:"labels"`
+	Dependencies   []string          `json:"dependencies,omitempty"`
+	DepStatus      map[string]string `json:"dep_status,omitempty"`
+	AgentID        int64             `json:"agent_id,omitempty"`
+	Agent          string            `json:"agent,omitempty"`
+	// WaitingSeconds is how long a queued workflow's pipeline has existed;
+	// RunningSeconds how long a running workflow has run
+	WaitingSeconds int64 `json:"waiting_seconds,omitempty"`
+	RunningSeconds int64 `json:"running_seconds,omitempty"`
+}
+
+func newTaskView(task woodpecker.Task, agentNames map[int64]string) taskView {
+	view := taskView{
+		WorkflowID:   task.ID,
+		Repo:         task.Labels["repo"],
+		Labels:       task.Labels,
+		Dependencies: task.Dependencies,
+		DepStatus:    task.DepStatus,
+		AgentID:      task.AgentID,
+		Agent:        agentNames[task.AgentID],
+	}
+	if view.Labels == nil {
+		view.Labels = map[string]string{}
+	}
+	return view
+}
+
+// queuedWorkflow is what resolveQueuedWorkflows finds out about a task's workflow
+type queuedWorkflow struct {
+	pipeline *woodpecker.Pipeline
+	workflow *woodpecker.Workflow
+}
+
+// resolveQueuedWorkflows finds the pipelines of tasks in views through the repo
+// label Woodpecker puts on each task, so their age can be reported. It searches
+// only recent active pipelines of a bounded number of repositories; tasks it
+// cannot place keep no timing.
+func (tm *ToolManager) resolveQueuedWorkflows(ctx context.Context, views []*taskView, now time.Time) {
+	var repos []string
+	seen := map[string]bool{}
+	for _, v := range views {
+		if v.Repo != "" && !seen[v.Repo] && len(repos) < maxQueueRepos {
+			seen[v.Repo] = true
+			repos = append(repos, v.Repo)
+		}
+	}
+
+	found := map[string]queuedWorkflow{}
+	fetched := 0
+	for _, name := range repos {
+		if fetched >= maxQueuePipelines {
+			break
+		}
+		repo, err := tm.client.LookupRepository(ctx, name)
+		if err != nil {
+			tm.logger.WithError(err).WithField("repo", name).Debug("Cannot resolve queued workflows of repository")
+			continue
+		}
+		pipelines, err := tm.client.ListPipelines(ctx, repo.ID, woodpecker.PipelineListOptions{
+			ListOptions: woodpecker.ListOptions{Page: 1, PerPage: queuePipelinePage},
+		})
+		if err != nil {
+			tm.logger.WithError(err).WithField("repo", name).Debug("Cannot resolve queued workflows of repository")
+			continue
+		}
+
+		for _, p := range pipelines {
+			if p.Status != "pending" && p.Status != "running" {
+				continue
+			}
+			if fetched >= maxQueuePipelines {
+				break
+			}
+			fetched++
+			pipeline, err := tm.client.GetPipeline(ctx, repo.ID, p.Number)
+			if err != nil {
+				continue
+			}
+			for _, w := range pipeline.Workflows {
+				found[strconv.FormatInt(w.ID, 10)] = queuedWorkflow{pipeline: pipeline, workflow: w}
+			}
+		}
+	}
+
+	for _, v := range views {
+		q, ok := found[v.WorkflowID]
+		if !ok {
+			continue
+		}
+		v.PipelineNumber = q.pipeline.Number
+		v.Workflow = q.workflow.Name
+		if q.workflow.Started > 0 {
+			v.RunningSeconds = int64(now.Sub(time.Unix(q.workflow.Started, 0)).Seconds())
+		} else if q.pipeline.Created > 0 {
+			v.WaitingSeconds = int64(now.Sub(time.Unix(q.pipeline.Created, 0)).Seconds())
+		}
+	}
+}
+
+func (tm *ToolManager) handleListAgents(ctx context.Context, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if cancelled := checkContextCancelled(ctx); cancelled != nil {
+		return cancelled, nil
+	}
+
+	agents, err := tm.client.ListAgents(ctx)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	// Running counts are a convenience; the list is still useful without them
+	var running map[int64]int
+	if info, err := tm.client.GetQueueInfo(ctx); err == nil && info != nil {
+		running = runningByAgent(info)
+	}
+
+	now := time.Now()
+	views := make([]agentView, 0, len(agents))
+	online := 0
+	for _, a := range agents {
+		view := newAgentView(a, now)
+		if running != nil {
+			count := running[a.ID]
+			view.RunningTasks = &count
+		}
+		if view.Online {
+			online++
+		}
+		views = append(views, view)
+	}
+
+	return tm.jsonResult(map[string]interface{}{
+		"agents": views,
+		"count":  len(views),
+		"online": online,
+	})
+}
+
+func (tm *ToolManager) handleGetAgent(ctx context.Context, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if cancelled := checkContextCancelled(ctx); cancelled != nil {
+		return cancelled, nil
+	}
+
+	agentID, err := requireNumber(arguments, "agent_id")
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	agent, err := tm.client.GetAgent(ctx, int64(agentID))
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	tasks, err := tm.client.ListAgentTasks(ctx, agent.ID)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	view := newAgentView(agent, time.Now())
+	count := len(tasks)
+	view.RunningTasks = &count
+
+	taskViews := make([]*taskView, 0, len(tasks))
+	for _, task := range tasks {
+		tv := newTaskView(*task, map[int64]string{agent.ID: agent.Name})
+		taskViews = append(taskViews, &tv)
+	}
+	tm.resolveQueuedWorkflows(ctx, taskViews, time.Now())
+
+	return tm.jsonResult(map[string]interface{}{
+		"agent": view,
+		"tasks": taskViews,
+	})
+}
+
+func (tm *ToolManager) handleGetQueueInfo(ctx context.Context, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if cancelled := checkContextCancelled(ctx); cancelled != nil {
+		return cancelled, nil
+	}
+
+	limit := clampInt(int(getNumber(arguments, "limit", defaultQueueLimit)), 1, maxQueueLimit)
+
+	info, err := tm.client.GetQueueInfo(ctx)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	// Agent names make running tasks readable; without them only IDs are shown
+	agentNames := map[int64]string{}
+	if agents, err := tm.client.ListAgents(ctx); err == nil {
+		for _, a := range agents {
+			agentNames[a.ID] = a.Name
+		}
+	}
+
+	lists := map[string][]woodpecker.Task{
+		queuePending:       info.Pending,
+		queueWaitingOnDeps: info.WaitingOnDeps,
+		queueRunning:       info.Running,
+	}
+	views := map[string][]*taskView{}
+	var all []*taskView
+	for name, tasks := range lists {
+		views[name] = []*taskView{}
+		for i, task := range tasks {
+			if i >= limit {
+				break
+			}
+			tv := newTaskView(task, agentNames)
+			views[name] = append(views[name], &tv)
+			all = append(all, &tv)
+		}
+	}
+	now := time.Now()
+	tm.resolveQueuedWorkflows(ctx, all, now)
+
+	var oldest int64
+	for _, tv := range views[queuePending] {
+		if tv.WaitingSeconds > oldest {
+			oldest = tv.WaitingSeconds
+		}
+	}
+
+	response := map[string]interface{}{
+		"paused": info.Paused,
+		"stats": map[string]int{
+			"workers":         info.Stats.Workers,
+			"pending":         info.Stats.Pending,
+			"waiting_on_deps": info.Stats.WaitingOnDeps,
+			"running":         info.Stats.Running,
+		},
+		queuePending:       views[queuePending],
+		queueWaitingOnDeps: views[queueWaitingOnDeps],
+		queueRunning:       views[queueRunning],
+	}
+	if oldest > 0 {
+		response["oldest_pending_seconds"] = oldest
+	}
+	if len(info.Pending) > limit || len(info.WaitingOnDeps) > limit || len(info.Running) > limit {
+		response["truncated"] = true
+	}
+	if info.Paused {
+		response["hint"] = "The queue is paused; no workflow starts until an admin resumes it."
+	} else if len(info.Pending) > 0 {
+		response["hint"] = "Use explain_pending_workflow on a pending workflow's pipeline to see which agents could run it and why they do not."
+	}
+	return tm.jsonResult(response)
+}
+
+// pendingExplanation is the result of explain_pending_workflow for one workflow
+type pendingExplanation struct {
+	Workflow       string            `json:"workflow"`
+	WorkflowID     int64             `json:"workflow_id"`
+	State          string            `json:"state"`
+	Queue          string            `json:"queue"`
+	QueuePosition  int               `json:"queue_position,omitempty"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	DepStatus      map[string]string `json:"dep_status,omitempty"`
+	Verdict        string            `json:"verdict"`
+	MatchingAgents []string          `json:"matching_agents"`
+	Agents         []agentMatch      `json:"agents,omitempty"`
+}
+
+// explainWorkflow locates a pending workflow in the queue and explains why no agent runs it
+func explainWorkflow(w *woodpecker.Workflow, pipeline *woodpecker.Pipeline, info *woodpecker.Info, agents []*woodpecker.Agent, now time.Time) pendingExplanation {
+	explanation := pendingExplanation{
+		Workflow:       w.Name,
+		WorkflowID:     w.ID,
+		State:          string(w.State),
+		Queue:          queueAbsent,
+		MatchingAgents: []string{},
+	}
+	id := strconv.FormatInt(w.ID, 10)
+
+	for i, task := range info.Pending {
+		if task.ID != id {
+			continue
+		}
+		explanation.Queue = queuePending
+		explanation.QueuePosition = i + 1
+		explanation.Labels = task.Labels
+		explanation.Agents = matchAgents(task, agents, runningByAgent(info), now)
+		for _, m := range explanation.Agents {
+			if m.LabelsMatch {
+				explanation.MatchingAgents = append(explanation.MatchingAgents, m.Name)
+			}
+		}
+		explanation.Verdict = pendingVerdict(explanation.Agents, info.Paused)
+		return explanation
+	}
+
+	for _, task := range info.WaitingOnDeps {
+		if task.ID == id {
+			explanation.Queue = queueWaitingOnDeps
+			explanation.Labels = task.Labels
+			explanation.DepStatus = task.DepStatus
+			explanation.Verdict = fmt.Sprintf("The workflow waits for the workflows it depends on (%s) to finish before it is offered to agents.", strings.Join(task.Dependencies, ", "))
+			return explanation
+		}
+	}
+
+	for _, task := range info.Running {
+		if task.ID == id {
+			explanation.Queue = queueRunning
+			explanation.Labels = task.Labels
+			explanation.Verdict = fmt.Sprintf("An agent (ID %d) has already taken the workflow; it is starting.", task.AgentID)
+			return explanation
+		}
+	}
+
+	if pipeline.Status == "blocked" {
+		explanation.Verdict = "The pipeline is blocked waiting for approval, so its workflows are not queued yet; approve it with approve_pipeline."
+	} else {
+		explanation.Verdict = "The workflow is not in the queue. The server may not have queued it yet, or it was lost, e.g. after a server restart; restarting the pipeline queues it again."
+	}
+	return explanation
+}
+
+func (tm *ToolManager) handleExplainPendingWorkflow(ctx context.Context, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if cancelled := checkContextCancelled(ctx); cancelled != nil {
+		return cancelled, nil
+	}
+
+	repoID, err := getRepoID(ctx, tm.client, arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	pipeline, err := tm.getPipelineArg(ctx, repoID, arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	workflowName := getString(arguments, "workflow_name", "")
+	var pending []*woodpecker.Workflow
+	states := map[string]string{}
+	for _, w := range pipeline.Workflows {
+		if workflowName != "" && w.Name != workflowName {
+			continue
+		}
+		states[w.Name] = string(w.State)
+		if w.State == "pending" {
+			pending = append(pending, w)
+		}
+	}
+
+	response := map[string]interface{}{
+		"repo_id":         repoID,
+		"pipeline_number": pipeline.Number,
+		"status":          pipeline.Status,
+	}
+
+	if workflowName != "" && len(states) == 0 {
+		names := make([]string, 0, len(pipeline.Workflows))
+		for _, w := range pipeline.Workflows {
+			names = append(names, w.Name)
+		}
+		return tm.errorResult(client.Validationf("workflow %q not found; available workflows: %s", workflowName, strings.Join(names, ", "))), nil
+	}
+	if len(pending) == 0 {
+		response["workflows"] = states
+		response["message"] = "No workflow of this pipeline is pending"
+		return tm.jsonResult(response)
+	}
+
+	info, err := tm.client.GetQueueInfo(ctx)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+	agents, err := tm.client.ListAgents(ctx)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	now := time.Now()
+	explanations := make([]pendingExplanation, 0, len(pending))
+	for _, w := range pending {
+		explanations = append(explanations, explainWorkflow(w, pipeline, info, agents, now))
+	}
+
+	response["queue_paused"] = info.Paused
+	response["agents"] = len(agents)
+	response["pending_workflows"] = explanations
+	response["note"] = "This is a heuristic based on the agents' labels, capacity and last contact as reported by the server"
+	return tm.jsonResult(response)
+}
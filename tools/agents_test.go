@@ -0,0 +1,145 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+func TestAgentLabels(t *testing.T) {
+	labels := agentLabels(&woodpecker.Agent{
+		Name:         "runner-1",
+		Platform:     "linux/amd64",
+		Backend:      "docker",
+		OrgID:        4,
+		CustomLabels: map[string]string{"gpu": "true"},
+	})
+
+	require.Equal(t, map[string]string{
+		"platform": "linux/amd64",
+		"backend":  "docker",
+		"hostname": "runner-1",
+		"repo":     "*",
+		"org-id":   "4",
+		"gpu":      "true",
+	}, labels)
+
+	require.Equal(t, "*", agentLabels(&woodpecker.Agent{OrgID: -1})["org-id"])
+}
+
+func TestLabelMismatches(t *testing.T) {
+	agent := map[string]string{"platform": "linux/amd64", "repo": "*", "gpu": "true"}
+
+	require.Empty(t, labelMismatches(map[string]string{"platform": "linux/amd64", "repo": "org/app", "zone": ""}, agent))
+
+	reasons := labelMismatches(map[string]string{"platform": "linux/arm64", "disk": "ssd"}, agent)
+	require.Equal(t, []string{
+		"agent has no disk label; the workflow needs disk=ssd",
+		"agent has platform=linux/amd64; the workflow needs platform=linux/arm64",
+	}, reasons)
+
+	required := map[string]string{"platform": "linux/amd64", "!gpu": "true"}
+	require.Equal(t, []string{"agent only takes workflows labelled gpu=true"}, labelMismatches(map[string]string{"platform": "linux/amd64"}, required))
+	require.Empty(t, labelMismatches(map[string]string{"platform": "linux/amd64", "gpu": "true"}, required))
+}
+
+func TestExplainWorkflow(t *testing.T) {
+	now := time.Unix(1_800_000_000, 0)
+	recent := now.Add(-time.Minute).Unix()
+
+	agents := []*woodpecker.Agent{
+		{ID: 1, Name: "amd64-busy", Platform: "linux/amd64", Capacity: 1, LastContact: recent},
+		{ID: 2, Name: "amd64-paused", Platform: "linux/amd64", Capacity: 2, LastContact: recent, NoSchedule: true},
+		{ID: 3, Name: "amd64-offline", Platform: "linux/amd64", Capacity: 2, LastContact: now.Add(-time.Hour).Unix()},
+		{ID: 4, Name: "arm64", Platform: "linux/arm64", Capacity: 2, LastContact: recent},
+	}
+	info := &woodpecker.Info{
+		Pending: []woodpecker.Task{
+			{ID: "10", Labels: map[string]string{"platform": "linux/riscv64", "repo": "org/app"}},
+			{ID: "11", Labels: map[string]string{"platform": "linux/amd64", "repo": "org/app"}},
+		},
+		WaitingOnDeps: []woodpecker.Task{{ID: "12", Dependencies: []string{"build"}}},
+		Running:       []woodpecker.Task{{ID: "13", AgentID: 1}},
+	}
+	pipeline := &woodpecker.Pipeline{Number: 5, Status: "pending"}
+
+	noMatch := explainWorkflow(&woodpecker.Workflow{ID: 10, Name: "riscv", State: "pending"}, pipeline, info, agents, now)
+	require.Equal(t, queuePending, noMatch.Queue)
+	require.Equal(t, 1, noMatch.QueuePosition)
+	require.Empty(t, noMatch.MatchingAgents)
+	require.Contains(t, noMatch.Verdict, "No agent's labels match")
+
+	unavailable := explainWorkflow(&woodpecker.Workflow{ID: 11, Name: "amd64", State: "pending"}, pipeline, info, agents, now)
+	require.Equal(t, []string{"amd64-busy", "amd64-paused", "amd64-offline"}, unavailable.MatchingAgents)
+	require.Contains(t, unavailable.Verdict, "none can take it now")
+	require.Contains(t, unavailable.Agents[0].Reasons[0], "busy running 1 of 1")
+	require.Contains(t, unavailable.Agents[1].Reasons[0], "paused")
+	require.Contains(t, unavailable.Agents[2].Reasons[0], "offline")
+	require.False(t, unavailable.Agents[3].LabelsMatch)
+
+	// A free matching agent means the workflow should start soon
+	agents[0].Capacity = 2
+	available := explainWorkflow(&woodpecker.Workflow{ID: 11, Name: "amd64", State: "pending"}, pipeline, info, agents, now)
+	require.True(t, available.Agents[0].Available)
+	require.Contains(t, available.Verdict, "free capacity")
+
+	deps := explainWorkflow(&woodpecker.Workflow{ID: 12, Name: "deploy", State: "pending"}, pipeline, info, agents, now)
+	require.Equal(t, queueWaitingOnDeps, deps.Queue)
+	require.Contains(t, deps.Verdict, "build")
+
+	blocked := explainWorkflow(&woodpecker.Workflow{ID: 14, Name: "test", State: "pending"}, &woodpecker.Pipeline{Status: "blocked"}, info, agents, now)
+	require.Equal(t, queueAbsent, blocked.Queue)
+	require.Contains(t, blocked.Verdict, "approve_pipeline")
+
+	info.Paused = true
+	paused := explainWorkflow(&woodpecker.Workflow{ID: 11, Name: "amd64", State: "pending"}, pipeline, info, agents, now)
+	require.Contains(t, paused.Verdict, "paused")
+}
+
+func TestNewAgentView_OnlineAndScope(t *testing.T) {
+	now := time.Unix(1_800_000_000, 0)
+
+	view := newAgentView(&woodpecker.Agent{ID: 1, Name: "a", LastContact: now.Add(-30 * time.Second).Unix()}, now)
+	require.True(t, view.Online)
+	require.Equal(t, "global", view.Scope)
+	require.Equal(t, int64(30), view.LastContactSecondsAgo)
+
+	view = newAgentView(&woodpecker.Agent{ID: 2, Name: "b", OrgID: 3}, now)
+	require.False(t, view.Online)
+	require.Equal(t, "org 3", view.Scope)
+	require.Empty(t, view.LastContact)
+}
+
+func TestResolveQueuedWorkflows_StopsAtPipelineBudget(t *testing.T) {
+	var lookups []string
+	pipelineFetches := 0
+	tm := newFakeWoodpecker(t, map[string]http.HandlerFunc{
+		"/api/repos/lookup/{name...}": func(w http.ResponseWriter, r *http.Request) {
+			lookups = append(lookups, r.PathValue("name"))
+			_, _ = w.Write([]byte(`{"id": 7, "full_name": "org/busy"}`))
+		},
+		"/api/repos/7/pipelines": func(w http.ResponseWriter, r *http.Request) {
+			pipelines := make([]woodpecker.Pipeline, maxQueuePipelines+5)
+			for i := range pipelines {
+				pipelines[i] = woodpecker.Pipeline{Number: int64(i + 1), Status: "pending"}
+			}
+			_ = json.NewEncoder(w).Encode(pipelines)
+		},
+		"/api/repos/7/pipelines/{number}": func(w http.ResponseWriter, r *http.Request) {
+			pipelineFetches++
+			_, _ = fmt.Fprintf(w, `{"number": %d}`, pipelineFetches)
+		},
+	})
+
+	views := []*taskView{{WorkflowID: "1", Repo: "org/busy"}, {WorkflowID: "2", Repo: "org/quiet"}}
+	tm.resolveQueuedWorkflows(context.Background(), views, time.Now())
+
+	require.Equal(t, maxQueuePipelines, pipelineFetches)
+	require.Equal(t, []string{"org/busy"}, lookups)
+}
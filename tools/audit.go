@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/audit"
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+)
+
+// SetAuditLogger records every tool call, including rejected ones, to logger
+func (tm *ToolManager) SetAuditLogger(logger *audit.Logger) {
+	tm.audit = logger
+}
+
+// recordAudit completes record from the call's result and writes it; failures
+// to write are logged but never fail the tool call
+func (tm *ToolManager) recordAudit(record audit.Record, result *mcp.CallToolResult, err error) {
+	if tm.audit == nil {
+		return
+	}
+
+	record.LatencyMS = time.Since(record.Time).Milliseconds()
+	if record.Outcome == "" {
+		record.Outcome = audit.OutcomeSuccess
+		switch {
+		case err != nil:
+			record.Outcome = audit.OutcomeError
+			record.ErrorCode = string(client.CodeOf(err))
+		case result != nil && result.IsError:
+			record.Outcome = audit.OutcomeError
+			record.ErrorCode = string(resultErrorCode(result))
+		}
+	}
+
+	if writeErr := tm.audit.Record(record); writeErr != nil {
+		tm.logger.WithError(writeErr).WithField("tool", record.Tool).Error("Failed to write audit record")
+	}
+}
+
+// resultErrorCode extracts the code from a result built by newErrorResult
+func resultErrorCode(result *mcp.CallToolResult) client.ErrorCode {
+	for _, content := range result.Content {
+		text, ok := content.(mcp.TextContent)
+		if !ok {
+			continue
+		}
+		var body struct {
+			Error toolError `json:"error"`
+		}
+		if json.Unmarshal([]byte(text.Text), &body) == nil && body.Error.Code != "" {
+			return body.Error.Code
+		}
+	}
+	return client.CodeInternal
+}
@@ -0,0 +1,71 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/audit"
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/config"
+)
+
+func callTool(t *testing.T, tm *ToolManager, name string, arguments map[string]interface{}) *mcp.CallToolResult {
+	t.Helper()
+
+	for _, st := range tm.GetServerTools() {
+		if st.Tool.Name != name {
+			continue
+		}
+		request := mcp.CallToolRequest{}
+		request.Params.Name = name
+		request.Params.Arguments = arguments
+		result, err := st.Handler(context.Background(), request)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Fatalf("tool %s not registered", name)
+	return nil
+}
+
+func TestGetToolHandler_WritesAuditRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	logger, err := audit.New(config.AuditConfig{Enabled: true, File: path})
+	require.NoError(t, err)
+
+	tm := newTestToolManager(t)
+	tm.SetAuditLogger(logger)
+
+	result := callTool(t, tm, "get_repository", map[string]interface{}{"repo_id": float64(7)})
+	require.False(t, result.IsError)
+
+	result = callTool(t, tm, "get_pipeline_status", map[string]interface{}{"repo_id": float64(7), "pipeline_number": float64(99)})
+	require.True(t, result.IsError)
+
+	require.NoError(t, logger.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+
+	var success, failure audit.Record
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &success))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &failure))
+
+	require.Equal(t, "get_repository", success.Tool)
+	require.Equal(t, audit.OutcomeSuccess, success.Outcome)
+	require.Equal(t, int64(7), success.RepoID)
+	require.Equal(t, "testuser", success.User)
+	require.Equal(t, float64(7), success.Arguments["repo_id"])
+
+	require.Equal(t, "get_pipeline_status", failure.Tool)
+	require.Equal(t, audit.OutcomeError, failure.Outcome)
+	require.Equal(t, "not_found", failure.ErrorCode)
+}
@@ -0,0 +1,254 @@
+package tools
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+)
+
+// confirmTokenArg is the argument that completes the second phase of a confirmation
+const confirmTokenArg = "confirm_token"
+
+// confirmations implements a two-phase protocol for tools that need human approval:
+// the first call returns a description of the action and a single-use token bound
+// to the exact arguments and caller, the second call with that token executes it
+type confirmations struct {
+	tools []string
+	ttl   time.Duration
+	now   func() time.Time
+
+	mu      sync.Mutex
+	pending map[string]pendingConfirmation
+}
+
+type pendingConfirmation struct {
+	digest  [sha256.Size]byte
+	expires time.Time
+}
+
+// SetConfirmation requires tools matching the glob patterns to be confirmed with a
+// confirm_token before they run; tokens expire after ttl
+func (tm *ToolManager) SetConfirmation(tools []string, ttl time.Duration) {
+	tm.confirm = &confirmations{
+		tools:   tools,
+		ttl:     ttl,
+		now:     time.Now,
+		pending: make(map[string]pendingConfirmation),
+	}
+}
+
+func (c *confirmations) requires(tool mcp.Tool) bool {
+	return c != nil && matchesAny(c.tools, tool.Name)
+}
+
+// issue stores a new token for digest, dropping any that have expired
+func (c *confirmations) issue(digest [sha256.Size]byte) (string, time.Time, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", time.Time{}, err
+	}
+	token := hex.EncodeToString(raw)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for t, p := range c.pending {
+		if now.After(p.expires) {
+			delete(c.pending, t)
+		}
+	}
+
+	expires := now.Add(c.ttl)
+	c.pending[token] = pendingConfirmation{digest: digest, expires: expires}
+	return token, expires, nil
+}
+
+// consume reports whether token is valid for digest; a token can be used only once
+func (c *confirmations) consume(token string, digest [sha256.Size]byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.pending[token]
+	if !ok {
+		return false
+	}
+	delete(c.pending, token)
+
+	return p.digest == digest && !c.now().After(p.expires)
+}
+
+// confirmationDigest binds a token to the tool, its arguments (without the token
+// itself) and the caller's Woodpecker identity
+func confirmationDigest(ctx context.Context, tool string, arguments map[string]interface{}) ([sha256.Size]byte, error) {
+	args := make(map[string]interface{}, len(arguments))
+	for k, v := range arguments {
+		if k != confirmTokenArg {
+			args[k] = v
+		}
+	}
+
+	// encoding/json sorts map keys, so equal arguments always encode identically
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+
+	caller, _ := client.TokenFromContext(ctx)
+	h := sha256.New()
+	h.Write([]byte(tool))
+	h.Write([]byte{0})
+	h.Write(encoded)
+	h.Write([]byte{0})
+	h.Write([]byte(caller))
+
+	var digest [sha256.Size]byte
+	copy(digest[:], h.Sum(nil))
+	return digest, nil
+}
+
+// requireConfirmation returns a confirmation request for the first phase of a
+// confirmed tool call, nil when the call may proceed, or an error for a bad token
+func (tm *ToolManager) requireConfirmation(ctx context.Context, tool mcp.Tool, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if !tm.confirm.requires(tool) {
+		return nil, nil
+	}
+
+	// Resolving first pins repo_id or org into arguments, so both phases act on
+	// the same target even when it was inferred
+	var repoID int64
+	var err error
+	switch getToolScope(tool.Name, arguments) {
+	case client.SecretLevelGlobal:
+	case client.SecretLevelOrg:
+		if _, err := tm.getOrgArg(ctx, arguments); err != nil {
+			return nil, err
+		}
+	default:
+		if repoID, err = getRepoID(ctx, tm.client, arguments); err != nil {
+			return nil, err
+		}
+	}
+
+	digest, err := confirmationDigest(ctx, tool.Name, arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	if token := getString(arguments, confirmTokenArg, ""); token != "" {
+		if tm.confirm.consume(token, digest) {
+			return nil, nil
+		}
+		return nil, client.Validationf("confirm_token is invalid, expired, already used or was issued for different arguments; call %s again without it to request a new confirmation", tool.Name)
+	}
+
+	action, err := tm.describeAction(ctx, tool.Name, repoID, arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	token, expires, err := tm.confirm.issue(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := tm.jsonResult(map[string]interface{}{
+		"confirmation_required": true,
+		"action":                action,
+		"confirm_token":         token,
+		"expires_at":            expires.UTC().Format(time.RFC3339),
+		"message":               "Nothing has been executed. Show this action to the user and, only after they explicitly accept it, call " + tool.Name + " again with the same arguments plus confirm_token.",
+	})
+	return result, err
+}
+
+// describeAction collects what a human needs to judge the call: the repository,
+// the name of the object acted on, the pipeline's number, branch and commit
+// where there is one, and the cron job's current schedule. Secret values are never included.
+func (tm *ToolManager) describeAction(ctx context.Context, tool string, repoID int64, arguments map[string]interface{}) (map[string]interface{}, error) {
+	action := map[string]interface{}{"tool": tool}
+	if name := getString(arguments, "name", ""); name != "" {
+		action["name"] = name
+	}
+	if address := getRegistryAddress(arguments); address != "" {
+		action["address"] = address
+	}
+
+	switch scope := getToolScope(tool, arguments); scope {
+	case client.SecretLevelGlobal:
+		action["scope"] = scope
+		return action, nil
+	case client.SecretLevelOrg:
+		action["scope"] = scope
+		action["org"] = getString(arguments, "org", "")
+		return action, nil
+	}
+
+	repo, err := tm.client.GetRepository(ctx, repoID)
+	if err != nil {
+		return nil, err
+	}
+	action["repo"] = repo.FullName
+	action["repo_id"] = repoID
+
+	if pipelineNum, ok := arguments["pipeline_number"].(float64); ok {
+		pipeline, err := tm.client.GetPipeline(ctx, repoID, int64(pipelineNum))
+		if err != nil {
+			return nil, err
+		}
+		action["pipeline_number"] = pipeline.Number
+		action["branch"] = pipeline.Branch
+		action["commit"] = pipeline.Commit
+		action["event"] = pipeline.Event
+		action["status"] = pipeline.Status
+		action["message"] = pipeline.Message
+		action["author"] = pipeline.Author
+		return action, nil
+	}
+
+	if cronID, ok := arguments["cron_id"].(float64); ok {
+		cron, err := tm.client.GetCron(ctx, repoID, int64(cronID))
+		if err != nil {
+			return nil, err
+		}
+		action["cron"] = map[string]interface{}{
+			"id":       cron.ID,
+			"name":     cron.Name,
+			"schedule": cron.Schedule,
+			"branch":   cron.Branch,
+		}
+	}
+
+	if tool == "trigger_pipeline" {
+		action["branch"] = triggerBranch(repo, arguments)
+		action["commit"] = "latest commit on the branch"
+		if variables, err := getVariables(arguments); err == nil && len(variables) > 0 {
+			action["variables"] = variables
+		}
+	}
+
+	return action, nil
+}
+
+// withConfirmTokenArg returns tool with the confirm_token argument added to its schema
+func withConfirmTokenArg(tool mcp.Tool) mcp.Tool {
+	properties := make(map[string]interface{}, len(tool.InputSchema.Properties)+1)
+	for k, v := range tool.InputSchema.Properties {
+		properties[k] = v
+	}
+	properties[confirmTokenArg] = map[string]interface{}{
+		"type":        "string",
+		"description": "Token from a previous confirmation_required response; pass it only after the user has accepted the described action",
+	}
+	tool.InputSchema.Properties = properties
+	tool.Description += ". Requires confirmation: the first call returns a confirm_token and a description of the action to show the user"
+	return tool
+}
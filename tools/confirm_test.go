@@ -0,0 +1,134 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+)
+
+func decodeConfirmation(t *testing.T, result *mcp.CallToolResult) map[string]interface{} {
+	t.Helper()
+	require.NotNil(t, result)
+	require.False(t, result.IsError)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &body))
+	require.Equal(t, true, body["confirmation_required"])
+	return body
+}
+
+func TestRequireConfirmation_TwoPhase(t *testing.T) {
+	tm := newTestToolManager(t)
+	tm.SetConfirmation([]string{"stop_pipeline"}, time.Minute)
+	tool := mcp.Tool{Name: "stop_pipeline", Annotations: mutatingTool(true)}
+	ctx := context.Background()
+
+	arguments := map[string]interface{}{"repo_id": float64(7), "pipeline_number": float64(3)}
+	result, err := tm.requireConfirmation(ctx, tool, arguments)
+	require.NoError(t, err)
+
+	body := decodeConfirmation(t, result)
+	action := body["action"].(map[string]interface{})
+	require.Equal(t, "org/prod-api", action["repo"])
+	require.Equal(t, float64(3), action["pipeline_number"])
+	require.Equal(t, "main", action["branch"])
+	require.Equal(t, "abc123", action["commit"])
+
+	token := body["confirm_token"].(string)
+	require.NotEmpty(t, token)
+
+	confirmed := map[string]interface{}{"repo_id": float64(7), "pipeline_number": float64(3), "confirm_token": token}
+	result, err = tm.requireConfirmation(ctx, tool, confirmed)
+	require.NoError(t, err)
+	require.Nil(t, result)
+
+	// Tokens are single-use
+	_, err = tm.requireConfirmation(ctx, tool, confirmed)
+	require.ErrorIs(t, err, client.ErrValidation)
+}
+
+func TestRequireConfirmation_TokenBoundToArgumentsAndCaller(t *testing.T) {
+	tm := newTestToolManager(t)
+	tm.SetConfirmation([]string{"stop_pipeline"}, time.Minute)
+	tool := mcp.Tool{Name: "stop_pipeline", Annotations: mutatingTool(true)}
+	ctx := client.WithToken(context.Background(), "alice")
+
+	result, err := tm.requireConfirmation(ctx, tool, map[string]interface{}{"repo_id": float64(7), "pipeline_number": float64(3)})
+	require.NoError(t, err)
+	token := decodeConfirmation(t, result)["confirm_token"].(string)
+
+	_, err = tm.requireConfirmation(ctx, tool, map[string]interface{}{"repo_id": float64(7), "pipeline_number": float64(4), "confirm_token": token})
+	require.ErrorIs(t, err, client.ErrValidation)
+
+	result, err = tm.requireConfirmation(ctx, tool, map[string]interface{}{"repo_id": float64(7), "pipeline_number": float64(3)})
+	require.NoError(t, err)
+	token = decodeConfirmation(t, result)["confirm_token"].(string)
+
+	other := client.WithToken(context.Background(), "mallory")
+	_, err = tm.requireConfirmation(other, tool, map[string]interface{}{"repo_id": float64(7), "pipeline_number": float64(3), "confirm_token": token})
+	require.ErrorIs(t, err, client.ErrValidation)
+}
+
+func TestRequireConfirmation_Expired(t *testing.T) {
+	tm := newTestToolManager(t)
+	tm.SetConfirmation([]string{"trigger_pipeline"}, time.Minute)
+	now := time.Now()
+	tm.confirm.now = func() time.Time { return now }
+	tool := mcp.Tool{Name: "trigger_pipeline", Annotations: mutatingTool(false)}
+	ctx := context.Background()
+
+	result, err := tm.requireConfirmation(ctx, tool, map[string]interface{}{"repo_id": float64(7), "branch": "dev"})
+	require.NoError(t, err)
+	body := decodeConfirmation(t, result)
+	require.Equal(t, "dev", body["action"].(map[string]interface{})["branch"])
+
+	now = now.Add(2 * time.Minute)
+	_, err = tm.requireConfirmation(ctx, tool, map[string]interface{}{"repo_id": float64(7), "branch": "dev", "confirm_token": body["confirm_token"]})
+	require.ErrorIs(t, err, client.ErrValidation)
+}
+
+func TestRequireConfirmation_OtherToolsRunDirectly(t *testing.T) {
+	tm := NewToolManager(nil, logrus.New())
+	tm.SetConfirmation([]string{"stop_pipeline"}, time.Minute)
+
+	result, err := tm.requireConfirmation(context.Background(), mcp.Tool{Name: "start_pipeline"}, map[string]interface{}{})
+	require.NoError(t, err)
+	require.Nil(t, result)
+}
+
+func TestGetServerTools_AddsConfirmTokenArgument(t *testing.T) {
+	tm := NewToolManager(nil, logrus.New())
+	tm.SetConfirmation([]string{"stop_pipeline"}, time.Minute)
+
+	for _, st := range tm.GetServerTools() {
+		_, ok := st.Tool.InputSchema.Properties[confirmTokenArg]
+		require.Equal(t, st.Tool.Name == "stop_pipeline", ok, st.Tool.Name)
+	}
+
+	// The base definitions must not be modified
+	for _, tool := range tm.tools {
+		require.NotContains(t, tool.InputSchema.Properties, confirmTokenArg)
+	}
+}
+
+func TestRequireConfirmation_IgnoresScopeOfUnscopedTools(t *testing.T) {
+	tm := newTestToolManager(t)
+	tm.SetConfirmation([]string{"stop_pipeline"}, time.Minute)
+	tool := mcp.Tool{Name: "stop_pipeline", Annotations: mutatingTool(true)}
+
+	arguments := map[string]interface{}{"repo_id": float64(7), "pipeline_number": float64(3), "scope": "global"}
+	result, err := tm.requireConfirmation(context.Background(), tool, arguments)
+	require.NoError(t, err)
+
+	action := decodeConfirmation(t, result)["action"].(map[string]interface{})
+	require.Equal(t, "org/prod-api", action["repo"])
+	require.Equal(t, "main", action["branch"])
+	require.NotContains(t, action, "scope")
+}
@@ -0,0 +1,303 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/cronspec"
+)
+
+const (
+	defaultNextRuns = 5
+	maxNextRuns     = 50
+)
+
+// cronView is a cron job with its upcoming run times worked out from its schedule
+type cronView struct {
+	*woodpecker.Cron
+	// NextExecAt is the server's own next run time, as a timestamp
+	NextExecAt *time.Time  `json:"next_exec_at,omitempty"`
+	NextRuns   []time.Time `json:"next_runs"`
+	// ScheduleError is set when the stored schedule cannot be parsed here
+	ScheduleError string `json:"schedule_error,omitempty"`
+}
+
+func newCronView(cron *woodpecker.Cron, from time.Time, runs int) cronView {
+	view := cronView{Cron: cron, NextRuns: []time.Time{}}
+	if cron.NextExec > 0 {
+		next := time.Unix(cron.NextExec, 0).UTC()
+		view.NextExecAt = &next
+	}
+
+	spec, err := cronspec.Parse(cron.Schedule)
+	if err != nil {
+		view.ScheduleError = err.Error()
+		return view
+	}
+	view.NextRuns = spec.NextN(from.UTC(), runs)
+	return view
+}
+
+// parseSchedule validates a schedule argument the way Woodpecker will
+func parseSchedule(schedule string) (*cronspec.Spec, error) {
+	spec, err := cronspec.Parse(schedule)
+	if err != nil {
+		return nil, client.Validationf("invalid schedule %q: %v; use five fields (minute hour day-of-month month day-of-week), a descriptor such as @daily, or @every <duration>", schedule, err)
+	}
+	return spec, nil
+}
+
+func getNextRunsArg(arguments map[string]interface{}) int {
+	return clampInt(int(getNumber(arguments, "next_runs", defaultNextRuns)), 0, maxNextRuns)
+}
+
+func getCronID(arguments map[string]interface{}) (int64, error) {
+	cronID, err := requireNumber(arguments, "cron_id")
+	if err != nil {
+		return 0, err
+	}
+	return int64(cronID), nil
+}
+
+// checkCronBranch rejects a branch the repository does not have, so the cron
+// does not silently fail at its first run. A branch that could not be checked is
+// let through with a note for the result.
+func (tm *ToolManager) checkCronBranch(ctx context.Context, repoID int64, branch string) (string, error) {
+	if branch == "" {
+		return "", nil
+	}
+	exists, err := tm.client.HasBranch(ctx, repoID, branch)
+	if note, ok := unverifiedBranchNote(err); ok {
+		return note, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", client.Validationf("branch %q does not exist in repository %d", branch, repoID)
+	}
+	return "", nil
+}
+
+// unverifiedBranchNote describes a branch HasBranch could not check, or reports
+// false for any other result
+func unverifiedBranchNote(err error) (string, bool) {
+	if !errors.Is(err, client.ErrBranchUnverified) {
+		return "", false
+	}
+	return fmt.Sprintf("The branch was not verified (%v)", err), true
+}
+
+func (tm *ToolManager) handleListCrons(ctx context.Context, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if cancelled := checkContextCancelled(ctx); cancelled != nil {
+		return cancelled, nil
+	}
+
+	repoID, err := getRepoID(ctx, tm.client, arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	crons, err := tm.client.ListCrons(ctx, repoID)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	now := time.Now()
+	runs := getNextRunsArg(arguments)
+	views := make([]cronView, 0, len(crons))
+	for _, cron := range crons {
+		views = append(views, newCronView(cron, now, runs))
+	}
+
+	return tm.jsonResult(map[string]interface{}{
+		"repo_id": repoID,
+		"crons":   views,
+		"count":   len(views),
+	})
+}
+
+func (tm *ToolManager) handleGetCron(ctx context.Context, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if cancelled := checkContextCancelled(ctx); cancelled != nil {
+		return cancelled, nil
+	}
+
+	repoID, err := getRepoID(ctx, tm.client, arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	cronID, err := getCronID(arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	cron, err := tm.client.GetCron(ctx, repoID, cronID)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	return tm.jsonResult(map[string]interface{}{
+		"repo_id": repoID,
+		"cron":    newCronView(cron, time.Now(), getNextRunsArg(arguments)),
+	})
+}
+
+func (tm *ToolManager) handleCreateCron(ctx context.Context, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if cancelled := checkContextCancelled(ctx); cancelled != nil {
+		return cancelled, nil
+	}
+
+	cron := &woodpecker.Cron{
+		Name:     strings.TrimSpace(getString(arguments, "name", "")),
+		Schedule: strings.TrimSpace(getString(arguments, "schedule", "")),
+		Branch:   strings.TrimSpace(getString(arguments, "branch", "")),
+	}
+	if cron.Name == "" {
+		return tm.errorResult(client.Validationf("name is required")), nil
+	}
+	if cron.Schedule == "" {
+		return tm.errorResult(client.Validationf("schedule is required")), nil
+	}
+	if _, err := parseSchedule(cron.Schedule); err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	repoID, err := getRepoID(ctx, tm.client, arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	branchNote, err := tm.checkCronBranch(ctx, repoID, cron.Branch)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	created, err := tm.client.CreateCron(ctx, repoID, cron)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	response := map[string]interface{}{
+		"repo_id": repoID,
+		"cron":    newCronView(created, time.Now(), getNextRunsArg(arguments)),
+	}
+	if created.Branch == "" {
+		response["message"] = "Cron created; it runs on the repository's default branch"
+	}
+	if branchNote != "" {
+		response["note"] = branchNote
+	}
+	return tm.jsonResult(response)
+}
+
+func (tm *ToolManager) handleUpdateCron(ctx context.Context, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if cancelled := checkContextCancelled(ctx); cancelled != nil {
+		return cancelled, nil
+	}
+
+	cronID, err := getCronID(arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	// Woodpecker keeps the current value of every empty field
+	cron := &woodpecker.Cron{
+		ID:       cronID,
+		Name:     strings.TrimSpace(getString(arguments, "name", "")),
+		Schedule: strings.TrimSpace(getString(arguments, "schedule", "")),
+		Branch:   strings.TrimSpace(getString(arguments, "branch", "")),
+	}
+	if cron.Name == "" && cron.Schedule == "" && cron.Branch == "" {
+		return tm.errorResult(client.Validationf("nothing to update; pass name, schedule or branch")), nil
+	}
+	if cron.Schedule != "" {
+		if _, err := parseSchedule(cron.Schedule); err != nil {
+			return tm.errorResult(err), nil
+		}
+	}
+
+	repoID, err := getRepoID(ctx, tm.client, arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	branchNote, err := tm.checkCronBranch(ctx, repoID, cron.Branch)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	updated, err := tm.client.UpdateCron(ctx, repoID, cron)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	response := map[string]interface{}{
+		"repo_id": repoID,
+		"cron":    newCronView(updated, time.Now(), getNextRunsArg(arguments)),
+	}
+	if branchNote != "" {
+		response["note"] = branchNote
+	}
+	return tm.jsonResult(response)
+}
+
+func (tm *ToolManager) handleDeleteCron(ctx context.Context, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if cancelled := checkContextCancelled(ctx); cancelled != nil {
+		return cancelled, nil
+	}
+
+	repoID, err := getRepoID(ctx, tm.client, arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	cronID, err := getCronID(arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	if err := tm.client.DeleteCron(ctx, repoID, cronID); err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	return tm.jsonResult(map[string]interface{}{
+		"repo_id": repoID,
+		"cron_id": cronID,
+		"deleted": true,
+	})
+}
+
+func (tm *ToolManager) handleRunCronNow(ctx context.Context, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if cancelled := checkContextCancelled(ctx); cancelled != nil {
+		return cancelled, nil
+	}
+
+	repoID, err := getRepoID(ctx, tm.client, arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	cronID, err := getCronID(arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	pipeline, err := tm.client.RunCron(ctx, repoID, cronID)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	return tm.jsonResult(map[string]interface{}{
+		"cron_id":  cronID,
+		"pipeline": pipeline,
+		"web_url":  tm.client.PipelineURL(repoID, pipeline.Number),
+	})
+}
@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+func TestNewCronView(t *testing.T) {
+	from := time.Date(2026, time.March, 6, 12, 0, 0, 0, time.UTC) // a Friday
+	cron := &woodpecker.Cron{ID: 3, Name: "nightly", Schedule: "0 3 * * 1-5", NextExec: from.Add(time.Hour).Unix()}
+
+	view := newCronView(cron, from, 3)
+	require.Equal(t, []time.Time{
+		time.Date(2026, time.March, 9, 3, 0, 0, 0, time.UTC),
+		time.Date(2026, time.March, 10, 3, 0, 0, 0, time.UTC),
+		time.Date(2026, time.March, 11, 3, 0, 0, 0, time.UTC),
+	}, view.NextRuns)
+	require.Equal(t, from.Add(time.Hour), *view.NextExecAt)
+	require.Empty(t, view.ScheduleError)
+
+	view = newCronView(&woodpecker.Cron{Schedule: "every day"}, from, 3)
+	require.Empty(t, view.NextRuns)
+	require.NotEmpty(t, view.ScheduleError)
+	require.Nil(t, view.NextExecAt)
+}
+
+func TestGetNextRunsArg(t *testing.T) {
+	require.Equal(t, defaultNextRuns, getNextRunsArg(map[string]interface{}{}))
+	require.Equal(t, maxNextRuns, getNextRunsArg(map[string]interface{}{"next_runs": float64(1000)}))
+	require.Equal(t, 0, getNextRunsArg(map[string]interface{}{"next_runs": float64(-1)}))
+}
+
+func TestCreateCron_RejectsInvalidSchedule(t *testing.T) {
+	tm := newTriggerTestManager(t, nil)
+
+	result := callTool(t, tm, "create_cron", map[string]interface{}{
+		"repo_id":  float64(7),
+		"name":     "nightly",
+		"schedule": "0 25 * * *",
+	})
+
+	require.True(t, result.IsError)
+	text := result.Content[0].(mcp.TextContent).Text
+	require.Contains(t, text, "invalid schedule")
+	require.Contains(t, text, "hour")
+}
+
+func TestCreateCron_RejectsUnknownBranch(t *testing.T) {
+	tm := newTriggerTestManager(t, nil)
+
+	result := callTool(t, tm, "create_cron", map[string]interface{}{
+		"repo_id":  float64(7),
+		"name":     "nightly",
+		"schedule": "@daily",
+		"branch":   "nope",
+	})
+
+	require.True(t, result.IsError)
+	require.Contains(t, result.Content[0].(mcp.TextContent).Text, "does not exist")
+}
+
+func TestUpdateCron_RequiresAChange(t *testing.T) {
+	tm := newTriggerTestManager(t, nil)
+
+	result := callTool(t, tm, "update_cron", map[string]interface{}{
+		"repo_id": float64(7),
+		"cron_id": float64(3),
+	})
+
+	require.True(t, result.IsError)
+	require.Contains(t, result.Content[0].(mcp.TextContent).Text, "nothing to update")
+}
+
+func TestRunCronNow(t *testing.T) {
+	tm := newTriggerTestManager(t, nil)
+
+	result := callTool(t, tm, "run_cron_now", map[string]interface{}{
+		"repo_id": float64(7),
+		"cron_id": float64(3),
+	})
+
+	require.False(t, result.IsError)
+	body := resultJSON(t, result)
+	require.Equal(t, "cron", body["pipeline"].(map[string]interface{})["event"])
+	require.Contains(t, body["web_url"], "/repos/7/pipeline/13")
+}
@@ -0,0 +1,204 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+)
+
+const (
+	defaultDiagnoseTailLines = 40
+	maxDiagnoseTailLines     = 500
+	diagnoseContextLines     = 3
+	maxDiagnoseExcerpts      = 3
+	// maxDiagnosedSteps bounds the log fetches for pipelines with many failures
+	maxDiagnosedSteps = 10
+)
+
+// errorMarker matches lines that commonly carry the reason a step failed
+var errorMarker = regexp.MustCompile(`(?i)(\berror\b|\berr:|\bfail(ed|ure)?\b|\bpanic:|\bfatal\b|\bexception\b|traceback|exit (code|status) [1-9])`)
+
+// logExcerpt is a window of log lines around one or more error markers
+type logExcerpt struct {
+	StartLine int       `json:"start_line"`
+	EndLine   int       `json:"end_line"`
+	Lines     []logLine `json:"lines"`
+}
+
+// stepDiagnosis is the evidence collected for one failed step
+type stepDiagnosis struct {
+	Workflow        string       `json:"workflow"`
+	Step            string       `json:"step"`
+	StepID          int64        `json:"step_id"`
+	State           string       `json:"state"`
+	ExitCode        int          `json:"exit_code"`
+	Error           string       `json:"error,omitempty"`
+	DurationSeconds int64        `json:"duration_seconds"`
+	TotalLines      int          `json:"total_lines"`
+	Excerpts        []logExcerpt `json:"excerpts"`
+	Tail            []logLine    `json:"tail"`
+	LogError        string       `json:"log_error,omitempty"`
+}
+
+func (tm *ToolManager) handleDiagnosePipeline(ctx context.Context, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if cancelled := checkContextCancelled(ctx); cancelled != nil {
+		return cancelled, nil
+	}
+
+	repoID, err := getRepoID(ctx, tm.client, arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	pipeline, err := tm.getPipelineArg(ctx, repoID, arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	tail := int(getNumber(arguments, "tail_lines", defaultDiagnoseTailLines))
+	if tail < 0 {
+		return tm.errorResult(client.Validationf("tail_lines must not be negative")), nil
+	}
+	if tail > maxDiagnoseTailLines {
+		tail = maxDiagnoseTailLines
+	}
+
+	workflows := buildStepTree(pipeline.Workflows, time.Now())
+
+	diagnoses := []stepDiagnosis{}
+	var workflowErrors []map[string]string
+	skipped := 0
+	for _, w := range workflows {
+		failedInWorkflow := 0
+		for _, s := range w.Steps {
+			if !s.Failed {
+				continue
+			}
+			failedInWorkflow++
+			if len(diagnoses) >= maxDiagnosedSteps {
+				skipped++
+				continue
+			}
+
+			diagnosis := stepDiagnosis{
+				Workflow:        w.Name,
+				Step:            s.Name,
+				StepID:          s.StepID,
+				State:           s.State,
+				ExitCode:        s.ExitCode,
+				Error:           s.Error,
+				DurationSeconds: s.DurationSeconds,
+				Excerpts:        []logExcerpt{},
+				Tail:            []logLine{},
+			}
+
+			entries, err := tm.client.GetStepLogs(ctx, repoID, pipeline.Number, s.StepID)
+			if err != nil {
+				// Keep the rest of the report useful when one log cannot be fetched
+				diagnosis.LogError = err.Error()
+			} else {
+				lines := decodeLogLines(entries)
+				diagnosis.TotalLines = len(lines)
+				diagnosis.Excerpts = findErrorExcerpts(lines, diagnoseContextLines, maxDiagnoseExcerpts)
+				// tailLines returns everything for 0, which is not what tail_lines 0 asks for
+				if tail > 0 {
+					diagnosis.Tail = tailLines(lines, tail)
+				}
+			}
+
+			diagnoses = append(diagnoses, diagnosis)
+		}
+
+		// Workflows can fail before any step runs, e.g. on an invalid config or missing agent
+		if w.Failed && failedInWorkflow == 0 {
+			workflowErrors = append(workflowErrors, map[string]string{
+				"workflow": w.Name,
+				"state":    w.State,
+				"error":    w.Error,
+			})
+		}
+	}
+
+	response := map[string]interface{}{
+		"repo_id":         repoID,
+		"pipeline_number": pipeline.Number,
+		"status":          pipeline.Status,
+		"event":           pipeline.Event,
+		"branch":          pipeline.Branch,
+		"commit":          pipeline.Commit,
+		"author":          pipeline.Author,
+		"message":         pipeline.Message,
+		"forge_url":       pipeline.ForgeURL,
+		"summary":         diagnosisSummary(diagnoses, workflowErrors, skipped),
+		"failed_steps":    diagnoses,
+	}
+	if len(workflowErrors) > 0 {
+		response["workflow_errors"] = workflowErrors
+	}
+	if skipped > 0 {
+		response["steps_not_diagnosed"] = skipped
+	}
+
+	return tm.jsonResult(response)
+}
+
+// findErrorExcerpts returns up to maxExcerpts windows of contextLines around the
+// first lines matching errorMarker; overlapping windows are merged
+func findErrorExcerpts(lines []logLine, contextLines, maxExcerpts int) []logExcerpt {
+	excerpts := []logExcerpt{}
+	end := -1 // index after the last line already covered
+
+	for i, line := range lines {
+		if i < end || !errorMarker.MatchString(line.Text) {
+			continue
+		}
+
+		from := max(i-contextLines, 0)
+		to := min(i+contextLines+1, len(lines))
+
+		if n := len(excerpts); n > 0 && from <= end {
+			// Extend the previous window instead of repeating lines
+			from = end
+			excerpts[n-1].Lines = append(excerpts[n-1].Lines, lines[from:to]...)
+			excerpts[n-1].EndLine = lines[to-1].Number
+		} else {
+			if len(excerpts) == maxExcerpts {
+				break
+			}
+			excerpts = append(excerpts, logExcerpt{
+				StartLine: lines[from].Number,
+				EndLine:   lines[to-1].Number,
+				Lines:     append([]logLine(nil), lines[from:to]...),
+			})
+		}
+		end = to
+	}
+
+	return excerpts
+}
+
+func diagnosisSummary(diagnoses []stepDiagnosis, workflowErrors []map[string]string, skipped int) string {
+	if len(diagnoses) == 0 && len(workflowErrors) == 0 {
+		return "No failed steps found"
+	}
+
+	var parts []string
+	for _, d := range diagnoses {
+		parts = append(parts, fmt.Sprintf("%s/%s (exit %d)", d.Workflow, d.Step, d.ExitCode))
+	}
+	for _, w := range workflowErrors {
+		parts = append(parts, fmt.Sprintf("workflow %s: %s", w["workflow"], w["error"]))
+	}
+
+	summary := fmt.Sprintf("%d failed: %s", len(diagnoses)+len(workflowErrors), strings.Join(parts, ", "))
+	if skipped > 0 {
+		summary += fmt.Sprintf(" (%d more failed steps not diagnosed)", skipped)
+	}
+	return summary
+}
@@ -0,0 +1,114 @@
+package tools
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+)
+
+func numberedLines(texts ...string) []logLine {
+	lines := make([]logLine, len(texts))
+	for i, text := range texts {
+		lines[i] = logLine{Number: i, Text: text}
+	}
+	return lines
+}
+
+func TestFindErrorExcerpts(t *testing.T) {
+	lines := numberedLines(
+		"go test ./...",
+		"ok  pkg/a",
+		"--- FAIL: TestB (0.00s)",
+		"    b_test.go:12: want 1, got 2",
+		"FAIL pkg/b",
+		"ok  pkg/c",
+		"ok  pkg/d",
+		"ok  pkg/e",
+		"ok  pkg/f",
+		"make: *** [test] Error 1",
+	)
+
+	excerpts := findErrorExcerpts(lines, 1, 3)
+	require.Len(t, excerpts, 2)
+
+	// The FAIL lines at 2 and 4 are close enough to share one window
+	require.Equal(t, 1, excerpts[0].StartLine)
+	require.Equal(t, 5, excerpts[0].EndLine)
+	require.Len(t, excerpts[0].Lines, 5)
+
+	require.Equal(t, 8, excerpts[1].StartLine)
+	require.Equal(t, 9, excerpts[1].EndLine)
+}
+
+func TestFindErrorExcerpts_LimitsExcerpts(t *testing.T) {
+	var texts []string
+	for i := 0; i < 10; i++ {
+		texts = append(texts, fmt.Sprintf("error %d", i), "filler", "filler", "filler")
+	}
+
+	excerpts := findErrorExcerpts(numberedLines(texts...), 1, 2)
+	require.Len(t, excerpts, 2)
+	require.Equal(t, "error 0", excerpts[0].Lines[0].Text)
+}
+
+func TestFindErrorExcerpts_NoMarkers(t *testing.T) {
+	excerpts := findErrorExcerpts(numberedLines("building", "done"), 3, 3)
+	require.NotNil(t, excerpts)
+	require.Empty(t, excerpts)
+}
+
+func TestDecodeLogLines(t *testing.T) {
+	lines := decodeLogLines([]*woodpecker.LogEntry{
+		{Line: 0, Data: []byte("hello\n")},
+		{Line: 1, Type: 1},
+		{Line: 2, Data: []byte("world\r\n")},
+	})
+
+	require.Equal(t, []logLine{{Number: 0, Text: "hello"}, {Number: 2, Text: "world"}}, lines)
+	require.Equal(t, lines[1:], tailLines(lines, 1))
+	require.Equal(t, lines, tailLines(lines, 0))
+}
+
+func TestDiagnosisSummary(t *testing.T) {
+	require.Equal(t, "No failed steps found", diagnosisSummary(nil, nil, 0))
+
+	summary := diagnosisSummary(
+		[]stepDiagnosis{{Workflow: "build", Step: "test", ExitCode: 2}},
+		[]map[string]string{{"workflow": "deploy", "error": "no matching agent"}},
+		1,
+	)
+	require.Equal(t, "2 failed: build/test (exit 2), workflow deploy: no matching agent (1 more failed steps not diagnosed)", summary)
+}
+
+func TestDiagnosePipeline_TailLines(t *testing.T) {
+	var entries []string
+	for i, text := range []string{"go test ./...", "FAIL: TestParse", "exit status 1"} {
+		entries = append(entries, fmt.Sprintf(`{"line": %d, "data": %q}`, i, base64.StdEncoding.EncodeToString([]byte(text))))
+	}
+	tm := newFakeWoodpecker(t, map[string]http.HandlerFunc{
+		"/api/repos/1/pipelines/4": jsonRoute(`{"number": 4, "status": "failure", "workflows": [{"name": "build", "state": "failure", "children": [{"id": 3, "name": "test", "state": "failure", "exit_code": 1}]}]}`),
+		"/api/repos/1/logs/4/3":    jsonRoute("[" + strings.Join(entries, ",") + "]"),
+	})
+
+	diagnose := func(tail interface{}) map[string]interface{} {
+		result := callTool(t, tm, "diagnose_pipeline", map[string]interface{}{"repo_id": float64(1), "pipeline_number": float64(4), "tail_lines": tail})
+		require.False(t, result.IsError)
+		return resultJSON(t, result)["failed_steps"].([]interface{})[0].(map[string]interface{})
+	}
+
+	step := diagnose(float64(0))
+	require.EqualValues(t, 3, step["total_lines"])
+	require.Empty(t, step["tail"])
+
+	require.Len(t, diagnose(float64(2))["tail"], 2)
+
+	result := callTool(t, tm, "diagnose_pipeline", map[string]interface{}{"repo_id": float64(1), "pipeline_number": float64(4), "tail_lines": float64(-1)})
+	require.Equal(t, client.CodeValidation, decodeToolError(t, result).Code)
+}
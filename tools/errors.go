@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+)
+
+// toolError is the JSON body returned to agents when a tool call fails.
+// Code values are stable and can be branched on.
+type toolError struct {
+	Code       client.ErrorCode `json:"code"`
+	Message    string           `json:"message"`
+	StatusCode int              `json:"status_code,omitempty"`
+	Retryable  bool             `json:"retryable"`
+	Hint       string           `json:"hint"`
+}
+
+// newErrorResult classifies err and renders it as a structured error result
+func newErrorResult(err error) *mcp.CallToolResult {
+	return renderToolError(newToolError(err))
+}
+
+func newToolError(err error) toolError {
+	e := client.AsError(err)
+	return toolError{
+		Code:       e.Code,
+		Message:    e.Error(),
+		StatusCode: e.StatusCode,
+		Retryable:  e.Retryable(),
+		Hint:       e.Hint(),
+	}
+}
+
+func renderToolError(te toolError) *mcp.CallToolResult {
+	body, marshalErr := json.MarshalIndent(map[string]interface{}{"error": te}, "", "  ")
+	if marshalErr != nil {
+		body = []byte(te.Message)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: string(body),
+			},
+		},
+		IsError: true,
+	}
+}
@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+)
+
+func decodeToolError(t *testing.T, result *mcp.CallToolResult) toolError {
+	t.Helper()
+	require.True(t, result.IsError)
+	require.Len(t, result.Content, 1)
+
+	text, ok := result.Content[0].(mcp.TextContent)
+	require.True(t, ok)
+
+	var body struct {
+		Error toolError `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(text.Text), &body))
+	return body.Error
+}
+
+func TestNewErrorResult_Validation(t *testing.T) {
+	_, err := requireNumber(map[string]interface{}{}, "pipeline_number")
+
+	body := decodeToolError(t, newErrorResult(err))
+
+	require.Equal(t, client.CodeValidation, body.Code)
+	require.Equal(t, "pipeline_number is required", body.Message)
+	require.False(t, body.Retryable)
+	require.NotEmpty(t, body.Hint)
+}
+
+func TestNewErrorResult_WrappedClassifiedError(t *testing.T) {
+	err := fmt.Errorf("failed to lookup repository: %w", &client.Error{
+		Code:       client.CodeRateLimited,
+		StatusCode: 429,
+		Message:    "failed to lookup repository foo/bar",
+		Err:        errors.New("client error 429"),
+	})
+
+	body := decodeToolError(t, newErrorResult(err))
+
+	require.Equal(t, client.CodeRateLimited, body.Code)
+	require.Equal(t, 429, body.StatusCode)
+	require.True(t, body.Retryable)
+	require.Contains(t, body.Message, "foo/bar")
+}
+
+func TestNewErrorResult_Unclassified(t *testing.T) {
+	body := decodeToolError(t, newErrorResult(errors.New("boom")))
+
+	require.Equal(t, client.CodeInternal, body.Code)
+	require.Equal(t, "boom", body.Message)
+	require.False(t, body.Retryable)
+}
+
+func TestCheckContextCancelled(t *testing.T) {
+	require.Nil(t, checkContextCancelled(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	body := decodeToolError(t, checkContextCancelled(ctx))
+	require.Equal(t, client.CodeCancelled, body.Code)
+}
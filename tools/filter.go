@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"path"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ToolFilter decides which tools are exposed to MCP clients.
+// Allow and Deny hold glob patterns (path.Match syntax) matched against tool names.
+type ToolFilter struct {
+	// ReadOnly hides every tool that is not annotated as read-only
+	ReadOnly bool
+	// Allow, when non-empty, exposes only tools matching at least one pattern
+	Allow []string
+	// Deny hides tools matching any pattern; it wins over Allow
+	Deny []string
+}
+
+// Allows reports whether tool should be exposed
+func (f ToolFilter) Allows(tool mcp.Tool) bool {
+	if f.ReadOnly && !isReadOnly(tool) {
+		return false
+	}
+	if matchesAny(f.Deny, tool.Name) {
+		return false
+	}
+	if len(f.Allow) > 0 && !matchesAny(f.Allow, tool.Name) {
+		return false
+	}
+	return true
+}
+
+// isReadOnly treats tools without a read-only hint as mutating so new tools fail closed
+func isReadOnly(tool mcp.Tool) bool {
+	return tool.Annotations.ReadOnlyHint != nil && *tool.Annotations.ReadOnlyHint
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, err := path.Match(pattern, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+func readOnlyTool() mcp.ToolAnnotation {
+	return mcp.ToolAnnotation{
+		ReadOnlyHint: mcp.ToBoolPtr(true),
+	}
+}
+
+func mutatingTool(destructive bool) mcp.ToolAnnotation {
+	return mcp.ToolAnnotation{
+		ReadOnlyHint:    mcp.ToBoolPtr(false),
+		DestructiveHint: mcp.ToBoolPtr(destructive),
+	}
+}
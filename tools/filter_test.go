@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func serverToolNames(tm *ToolManager) []string {
+	var names []string
+	for _, tool := range tm.GetServerTools() {
+		names = append(names, tool.Tool.Name)
+	}
+	return names
+}
+
+func TestToolFilter_Allows(t *testing.T) {
+	readTool := mcp.Tool{Name: "get_logs", Annotations: readOnlyTool()}
+	writeTool := mcp.Tool{Name: "stop_pipeline", Annotations: mutatingTool(true)}
+	unannotated := mcp.Tool{Name: "new_tool"}
+
+	require.True(t, ToolFilter{}.Allows(writeTool))
+
+	readOnly := ToolFilter{ReadOnly: true}
+	require.True(t, readOnly.Allows(readTool))
+	require.False(t, readOnly.Allows(writeTool))
+	require.False(t, readOnly.Allows(unannotated))
+
+	allow := ToolFilter{Allow: []string{"get_*", "list_*"}}
+	require.True(t, allow.Allows(readTool))
+	require.False(t, allow.Allows(writeTool))
+
+	deny := ToolFilter{Allow: []string{"*"}, Deny: []string{"*_pipeline"}}
+	require.True(t, deny.Allows(readTool))
+	require.False(t, deny.Allows(writeTool))
+}
+
+func TestGetServerTools_ReadOnly(t *testing.T) {
+	tm := NewToolManager(nil, logrus.New())
+	tm.SetToolFilter(ToolFilter{ReadOnly: true})
+
+	names := serverToolNames(tm)
+
+	require.Contains(t, names, "list_pipelines")
+	require.Contains(t, names, "get_logs")
+	for _, mutating := range []string{"start_pipeline", "stop_pipeline", "approve_pipeline", "trigger_pipeline"} {
+		require.NotContains(t, names, mutating)
+	}
+}
+
+func TestInitializeTools_AllAnnotated(t *testing.T) {
+	tm := NewToolManager(nil, logrus.New())
+
+	for _, tool := range tm.tools {
+		require.NotNil(t, tool.Annotations.ReadOnlyHint, "tool %s has no read-only hint", tool.Name)
+	}
+}
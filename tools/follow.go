@@ -0,0 +1,133 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+const (
+	defaultFollowTimeoutSeconds = 60
+	maxFollowTimeoutSeconds     = 600
+	defaultFollowLines          = 100
+	maxFollowLines              = 1000
+)
+
+func (tm *ToolManager) handleFollowLogs(ctx context.Context, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if cancelled := checkContextCancelled(ctx); cancelled != nil {
+		return cancelled, nil
+	}
+
+	repoID, err := getRepoID(ctx, tm.client, arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	pipelineNum, err := requireNumber(arguments, "pipeline_number")
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	stepID, err := tm.getStepIDArg(ctx, repoID, int64(pipelineNum), arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	timeout := clampInt(int(getNumber(arguments, "timeout_seconds", defaultFollowTimeoutSeconds)), 1, maxFollowTimeoutSeconds)
+	keep := clampInt(int(getNumber(arguments, "lines", defaultFollowLines)), 1, maxFollowLines)
+	afterLine := int(getNumber(arguments, "after_line", -1))
+	opts := logOptions{
+		StripANSI:        getBool(arguments, "strip_ansi", true),
+		CollapseProgress: true,
+	}
+
+	notifier := tm.newNotifier(ctx, "follow_logs")
+	lines := []logEntry{}
+	received := 0
+	lastLine := afterLine
+	lastProgress := false
+
+	followCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	err = tm.client.FollowStepLogs(followCtx, repoID, int64(pipelineNum), stepID, afterLine, func(raw *woodpecker.LogEntry) {
+		entry := normalizeLogEntries([]*woodpecker.LogEntry{raw}, opts)[0]
+		lastLine = entry.Line
+		received++
+
+		// Entries arrive one at a time, so a run of progress entries is collapsed
+		// here by replacing the previous one, as get_logs does for a whole log
+		progress := raw.Type == woodpecker.LogEntryProgress
+		if progress && lastProgress && len(lines) > 0 {
+			lines[len(lines)-1] = entry
+		} else {
+			lines = append(lines, entry)
+			if len(lines) > keep {
+				lines = lines[len(lines)-keep:]
+			}
+		}
+		lastProgress = progress
+
+		if entry.Type != "stdout" || entry.Data == "" {
+			return
+		}
+		text := entry.Data
+		if tm.redactor != nil {
+			text, _ = tm.redactor.RedactString(text)
+		}
+		notifier.notify(ctx, text, map[string]interface{}{
+			"step_id": stepID,
+			"line":    entry.Line,
+			"text":    text,
+		})
+	})
+
+	if cancelled := checkContextCancelled(ctx); cancelled != nil {
+		return cancelled, nil
+	}
+	timedOut := err != nil && errors.Is(followCtx.Err(), context.DeadlineExceeded)
+	if err != nil && !timedOut {
+		return tm.errorResult(err), nil
+	}
+
+	response := map[string]interface{}{
+		"repo_id":         repoID,
+		"pipeline_number": int64(pipelineNum),
+		"step_id":         stepID,
+		"finished":        !timedOut,
+		"timed_out":       timedOut,
+		"lines_received":  received,
+		"last_line":       lastLine,
+		"logs":            lines,
+		"omitted":         received - len(lines),
+	}
+
+	if timedOut {
+		response["hint"] = "The step is still running; call follow_logs again with after_line set to last_line to continue"
+	} else if step := tm.lookupStep(ctx, repoID, int64(pipelineNum), stepID); step != nil {
+		response["state"] = string(step.State)
+		response["exit_code"] = step.ExitCode
+	}
+
+	return tm.jsonResult(response)
+}
+
+// lookupStep returns the current state of a step, or nil if it cannot be fetched
+func (tm *ToolManager) lookupStep(ctx context.Context, repoID, pipelineNum, stepID int64) *woodpecker.Step {
+	pipeline, err := tm.client.GetPipeline(ctx, repoID, pipelineNum)
+	if err != nil {
+		tm.logger.WithError(err).Debug("Failed to fetch step state after following logs")
+		return nil
+	}
+	for _, w := range pipeline.Workflows {
+		for _, s := range w.Children {
+			if s.ID == stepID {
+				return s
+			}
+		}
+	}
+	return nil
+}
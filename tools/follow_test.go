@@ -0,0 +1,165 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/require"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+// newMCPTestServer serves the tools against a fake Woodpecker API serving routes,
+// see newFakeWoodpecker
+func newMCPTestServer(t *testing.T, routes map[string]http.HandlerFunc) *server.MCPServer {
+	t.Helper()
+
+	srv := server.NewMCPServer("test", "1.0.0", server.WithLogging())
+	srv.AddTools(newFakeWoodpecker(t, routes).GetServerTools()...)
+	return srv
+}
+
+// newFollowTestServer serves step 3 of pipeline 2 in repo 1; stream writes the log stream
+func newFollowTestServer(t *testing.T, stream func(w http.ResponseWriter, r *http.Request)) *server.MCPServer {
+	t.Helper()
+
+	return newMCPTestServer(t, map[string]http.HandlerFunc{
+		"/api/repos/1/pipelines/2": jsonRoute(`{"number": 2, "workflows": [{"id": 10, "name": "build", "children": [{"id": 3, "name": "test", "state": "success", "exit_code": 0}]}]}`),
+		"/api/stream/logs/1/2/3": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			stream(w, r)
+		},
+	})
+}
+
+// testSession is a client session that records the notifications it is sent
+type testSession struct {
+	notifications chan mcp.JSONRPCNotification
+	level         mcp.LoggingLevel
+}
+
+func newTestSession(level mcp.LoggingLevel) *testSession {
+	return &testSession{notifications: make(chan mcp.JSONRPCNotification, 100), level: level}
+}
+
+func (s *testSession) Initialize()                                         {}
+func (s *testSession) Initialized() bool                                   { return true }
+func (s *testSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return s.notifications }
+func (s *testSession) SessionID() string                                   { return "test" }
+func (s *testSession) SetLogLevel(level mcp.LoggingLevel)                  { s.level = level }
+func (s *testSession) GetLogLevel() mcp.LoggingLevel                       { return s.level }
+
+func writeStreamLine(w http.ResponseWriter, line int, text string) {
+	writeStreamEntry(w, line, woodpecker.LogEntryStdout, text)
+}
+
+func writeStreamEntry(w http.ResponseWriter, line int, typ woodpecker.LogEntryType, text string) {
+	_, _ = fmt.Fprintf(w, "data: {\"line\":%d,\"time\":%d,\"data\":%q,\"type\":%d}\n\n", line, line, base64.StdEncoding.EncodeToString([]byte(text)), typ)
+	w.(http.Flusher).Flush()
+}
+
+// callWithSession calls a tool through srv and returns the result and the notifications sent meanwhile
+func callWithSession(t *testing.T, srv *server.MCPServer, session *testSession, params string) (map[string]interface{}, []mcp.JSONRPCNotification) {
+	t.Helper()
+
+	ctx := srv.WithContext(context.Background(), session)
+	message := fmt.Sprintf(`{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": %s}`, params)
+
+	response, ok := srv.HandleMessage(ctx, json.RawMessage(message)).(mcp.JSONRPCResponse)
+	require.True(t, ok)
+	result, ok := response.Result.(mcp.CallToolResult)
+	require.True(t, ok)
+	require.False(t, result.IsError, result.Content[0].(mcp.TextContent).Text)
+
+	var notifications []mcp.JSONRPCNotification
+	for len(session.notifications) > 0 {
+		notifications = append(notifications, <-session.notifications)
+	}
+	return resultJSON(t, &result), notifications
+}
+
+func TestFollowLogs_ForwardsProgressUntilStepFinishes(t *testing.T) {
+	srv := newFollowTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeStreamLine(w, 0, "\x1b[32mok\x1b[0m")
+		writeStreamLine(w, 1, "done")
+		_, _ = w.Write([]byte("event: error\ndata: eof\n\n"))
+	})
+
+	body, notifications := callWithSession(t, srv, newTestSession(mcp.LoggingLevelError), `{
+		"name": "follow_logs",
+		"arguments": {"repo_id": 1, "pipeline_number": 2, "step_name": "test"},
+		"_meta": {"progressToken": "tok"}
+	}`)
+
+	require.Equal(t, true, body["finished"])
+	require.Equal(t, false, body["timed_out"])
+	require.Equal(t, "success", body["state"])
+	require.EqualValues(t, 2, body["lines_received"])
+	require.EqualValues(t, 1, body["last_line"])
+	require.Len(t, body["logs"], 2)
+
+	require.Len(t, notifications, 2)
+	require.Equal(t, "notifications/progress", notifications[0].Method)
+	require.Equal(t, "tok", notifications[0].Params.AdditionalFields["progressToken"])
+	require.Equal(t, "ok", notifications[0].Params.AdditionalFields["message"])
+	require.Equal(t, float64(2), notifications[1].Params.AdditionalFields["progress"])
+}
+
+func TestFollowLogs_CollapsesProgressRuns(t *testing.T) {
+	srv := newFollowTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeStreamLine(w, 0, "pulling")
+		writeStreamEntry(w, 1, woodpecker.LogEntryProgress, "10%")
+		writeStreamEntry(w, 2, woodpecker.LogEntryProgress, "50%")
+		writeStreamEntry(w, 3, woodpecker.LogEntryProgress, "100%")
+		writeStreamLine(w, 4, "pulled")
+		_, _ = w.Write([]byte("event: error\ndata: eof\n\n"))
+	})
+
+	body, _ := callWithSession(t, srv, newTestSession(mcp.LoggingLevelError), `{
+		"name": "follow_logs",
+		"arguments": {"repo_id": 1, "pipeline_number": 2, "step_id": 3}
+	}`)
+
+	require.EqualValues(t, 5, body["lines_received"])
+	logs := body["logs"].([]interface{})
+	require.Len(t, logs, 3)
+	require.Equal(t, "100%", logs[1].(map[string]interface{})["data"])
+	require.EqualValues(t, 3, logs[1].(map[string]interface{})["line"])
+}
+
+func TestFollowLogs_SendsLogMessagesWithoutProgressToken(t *testing.T) {
+	srv := newFollowTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeStreamLine(w, 0, "compiling")
+		_, _ = w.Write([]byte("event: error\ndata: eof\n\n"))
+	})
+	_, notifications := callWithSession(t, srv, newTestSession(mcp.LoggingLevelInfo), `{
+		"name": "follow_logs",
+		"arguments": {"repo_id": 1, "pipeline_number": 2, "step_id": 3}
+	}`)
+
+	require.Len(t, notifications, 1)
+	require.Equal(t, "notifications/message", notifications[0].Method)
+	require.Equal(t, "follow_logs", notifications[0].Params.AdditionalFields["logger"])
+}
+
+func TestFollowLogs_TimesOutWhileStepRuns(t *testing.T) {
+	srv := newFollowTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeStreamLine(w, 0, "still working")
+		<-r.Context().Done()
+	})
+
+	body, _ := callWithSession(t, srv, newTestSession(mcp.LoggingLevelError), `{
+		"name": "follow_logs",
+		"arguments": {"repo_id": 1, "pipeline_number": 2, "step_id": 3, "timeout_seconds": 1}
+	}`)
+
+	require.Equal(t, false, body["finished"])
+	require.Equal(t, true, body["timed_out"])
+	require.EqualValues(t, 0, body["last_line"])
+	require.Contains(t, body["hint"], "after_line")
+}
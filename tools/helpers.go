@@ -17,38 +17,43 @@ import (
 // 1. repo_id from arguments
 // 2. repo_name from arguments (looks up the repository)
 // 3. git remote inference (if neither repo_id nor repo_name is provided)
-func getRepoID(wclient *client.Client, arguments map[string]interface{}) (int64, error) {
+//
+// A looked-up ID is stored back into arguments as repo_id, so later steps of the
+// same call (and the audit record) reuse it instead of resolving it again.
+func getRepoID(ctx context.Context, wclient *client.Client, arguments map[string]interface{}) (int64, error) {
 	// Try repo_id first
 	if repoID, ok := arguments["repo_id"]; ok {
 		if repoIDFloat, ok := repoID.(float64); ok {
 			return int64(repoIDFloat), nil
 		}
-		return 0, fmt.Errorf("repo_id must be a number")
+		return 0, client.Validationf("repo_id must be a number")
 	}
 
 	// Try repo_name second
 	if repoName, ok := arguments["repo_name"]; ok {
 		if repoNameStr, ok := repoName.(string); ok {
-			repo, err := wclient.LookupRepository(repoNameStr)
+			repo, err := wclient.LookupRepository(ctx, repoNameStr)
 			if err != nil {
 				return 0, fmt.Errorf("failed to lookup repository: %w", err)
 			}
+			arguments["repo_id"] = float64(repo.ID)
 			return repo.ID, nil
 		}
-		return 0, fmt.Errorf("repo_name must be a string")
+		return 0, client.Validationf("repo_name must be a string")
 	}
 
 	// Try to infer from git remote as last resort
 	repoName, err := getRepoNameFromRemote()
 	if err == nil {
-		repo, lookupErr := wclient.LookupRepository(repoName)
+		repo, lookupErr := wclient.LookupRepository(ctx, repoName)
 		if lookupErr == nil {
+			arguments["repo_id"] = float64(repo.ID)
 			return repo.ID, nil
 		}
 		return 0, fmt.Errorf("failed to lookup inferred repository %s: %w", repoName, lookupErr)
 	}
 
-	return 0, fmt.Errorf("either repo_id, repo_name must be provided, or git remote must be available")
+	return 0, client.Validationf("either repo_id, repo_name must be provided, or git remote must be available")
 }
 
 // getBool returns the boolean value for a key, or defaultValue if not present or invalid type
@@ -87,24 +92,16 @@ func requireNumber(arguments map[string]interface{}, key string) (float64, error
 		if numVal, ok := val.(float64); ok {
 			return numVal, nil
 		}
-		return 0, fmt.Errorf("%s must be a number", key)
+		return 0, client.Validationf("%s must be a number", key)
 	}
-	return 0, fmt.Errorf("%s is required", key)
+	return 0, client.Validationf("%s is required", key)
 }
 
 // checkContextCancelled returns a cancellation error result if the context is done
 func checkContextCancelled(ctx context.Context) *mcp.CallToolResult {
 	select {
 	case <-ctx.Done():
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.TextContent{
-					Type: "text",
-					Text: "request cancelled",
-				},
-			},
-			IsError: true,
-		}
+		return newErrorResult(&client.Error{Code: client.CodeCancelled, Message: "request cancelled", Err: ctx.Err()})
 	default:
 		return nil
 	}
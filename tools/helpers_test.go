@@ -1,11 +1,49 @@
 package tools
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
 )
 
+// newFakeWoodpecker returns a ToolManager backed by a fake Woodpecker API serving
+// routes, keyed by http.ServeMux patterns such as "POST /api/repos/7/pipelines".
+// The user lookup done when connecting is answered unless routes override it, and
+// anything else gets a 404.
+func newFakeWoodpecker(t *testing.T, routes map[string]http.HandlerFunc) *ToolManager {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	if _, ok := routes["/api/user"]; !ok {
+		mux.HandleFunc("/api/user", jsonRoute(`{"id": 1, "login": "testuser"}`))
+	}
+	for pattern, handler := range routes {
+		mux.HandleFunc(pattern, handler)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	wclient, err := client.New(client.Config{URL: server.URL, Token: "test-token"}, logrus.New())
+	require.NoError(t, err)
+	return NewToolManager(wclient, logrus.New())
+}
+
+// jsonRoute answers every request with body
+func jsonRoute(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}
+}
+
 func TestGetBool_WithValidBool(t *testing.T) {
 	arguments := map[string]interface{}{
 		"enabled": true,
@@ -0,0 +1,155 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	yaml "go.woodpecker-ci.org/woodpecker/v3/pipeline/frontend/yaml"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+)
+
+// defaultRegistryHost is where images without a registry host are pulled from
+const defaultRegistryHost = "docker.io"
+
+// dockerHubAliases are other names for Docker Hub found in registry addresses
+var dockerHubAliases = []string{"index.docker.io", "registry-1.docker.io", "registry.hub.docker.com"}
+
+// configImage is an image used by one step of a workflow file
+type configImage struct {
+	Image   string
+	File    string
+	Section string
+	Step    string
+}
+
+func (i configImage) usedBy() string {
+	return fmt.Sprintf("%s: %s.%s", i.File, i.Section, i.Step)
+}
+
+// readConfigImages returns the images used by the workflow file at path or by the
+// workflow files directly inside the directory at path. Without a path it looks
+// where Woodpecker does: .woodpecker/, then .woodpecker.yaml and .woodpecker.yml.
+func readConfigImages(path string) ([]string, []configImage, error) {
+	files, err := workflowFiles(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var images []configImage
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, nil, client.Validationf("failed to read file: %v", err)
+		}
+		found, err := workflowImages(filepath.Base(file), data)
+		if err != nil {
+			return nil, nil, err
+		}
+		images = append(images, found...)
+	}
+	return files, images, nil
+}
+
+func workflowFiles(path string) ([]string, error) {
+	if path == "" {
+		for _, candidate := range []string{".woodpecker", ".woodpecker.yaml", ".woodpecker.yml"} {
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+		}
+		if path == "" {
+			return nil, client.Validationf("no .woodpecker directory or .woodpecker.yaml found in the working directory; pass path")
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, client.Validationf("failed to read %s: %v", path, err)
+	}
+	if !info.IsDir() {
+		if !isWorkflowFile(path) {
+			return nil, client.Validationf("path must be a .yaml or .yml file or a directory")
+		}
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, client.Validationf("failed to read %s: %v", path, err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && isWorkflowFile(entry.Name()) {
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+	}
+	if len(files) == 0 {
+		return nil, client.Validationf("no .yaml or .yml files in %s", path)
+	}
+	return files, nil
+}
+
+func isWorkflowFile(name string) bool {
+	return strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml")
+}
+
+// workflowImages lists the images in one workflow, parsed the same way lint_config
+// parses it, in the order clone, services, then steps.
+func workflowImages(file string, data []byte) ([]configImage, error) {
+	workflow, err := yaml.ParseString(string(data))
+	if err != nil {
+		return nil, client.Validationf("failed to parse YAML in %s: %v", file, err)
+	}
+
+	var images []configImage
+	add := func(section, name, image string) {
+		if image = strings.TrimSpace(image); image != "" {
+			images = append(images, configImage{Image: image, File: file, Section: section, Step: name})
+		}
+	}
+	for _, c := range workflow.Clone.ContainerList {
+		add("clone", c.Name, c.Image)
+	}
+	for _, c := range workflow.Services.ContainerList {
+		add("services", c.Name, c.Image)
+	}
+	for _, c := range workflow.Steps.ContainerList {
+		add("steps", c.Name, c.Image)
+	}
+	return images, nil
+}
+
+// imageRegistry returns the registry host an image is pulled from. As in Docker,
+// the first path component is a host only if it looks like one.
+func imageRegistry(image string) string {
+	first, _, ok := strings.Cut(image, "/")
+	if !ok || !(strings.ContainsAny(first, ".:") || first == "localhost") {
+		return defaultRegistryHost
+	}
+	return normalizeRegistryAddress(first)
+}
+
+// normalizeRegistryAddress reduces a registry address to its host, so that
+// https://index.docker.io/v1/ and docker.io compare equal
+func normalizeRegistryAddress(address string) string {
+	address = strings.ToLower(strings.TrimSpace(address))
+	if _, rest, ok := strings.Cut(address, "://"); ok {
+		address = rest
+	}
+	host, _, _ := strings.Cut(address, "/")
+	for _, alias := range dockerHubAliases {
+		if host == alias {
+			return defaultRegistryHost
+		}
+	}
+	return host
+}
+
+// isTemplatedImage reports whether the image is only known once variables are substituted
+func isTemplatedImage(image string) bool {
+	return strings.Contains(image, "$")
+}
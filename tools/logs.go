@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"regexp"
+	"strings"
+
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+// ansiEscape matches CSI sequences (colours, cursor movement), OSC sequences
+// (titles, hyperlinks) and the remaining two-byte escapes
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+
+// logLine is one line of step output with its line number in the step log
+type logLine struct {
+	Number int    `json:"line"`
+	Text   string `json:"text"`
+}
+
+// logOptions controls how get_logs renders entries
+type logOptions struct {
+	StripANSI        bool
+	CollapseProgress bool
+}
+
+// logEntry is a normalised step log entry. Woodpecker merges stderr into stdout,
+// so output entries are all reported as stdout.
+type logEntry struct {
+	Line int    `json:"line"`
+	Time int64  `json:"time"`
+	Type string `json:"type"`
+	Data string `json:"data"`
+}
+
+// logEntryTypeName names an entry type; Time on entries is seconds since step start
+func logEntryTypeName(t woodpecker.LogEntryType) string {
+	switch t {
+	case woodpecker.LogEntryStdout:
+		return "stdout"
+	case woodpecker.LogEntryExitCode:
+		return "exit_code"
+	case woodpecker.LogEntryMetadata:
+		return "metadata"
+	case woodpecker.LogEntryProgress:
+		return "progress"
+	}
+	return "unknown"
+}
+
+// normalizeLogEntries converts raw entries according to opts. With CollapseProgress,
+// text overwritten by carriage returns is dropped and only the last of a run of
+// progress entries is kept.
+func normalizeLogEntries(entries []*woodpecker.LogEntry, opts logOptions) []logEntry {
+	normalized := make([]logEntry, 0, len(entries))
+	for i, entry := range entries {
+		if opts.CollapseProgress && entry.Type == woodpecker.LogEntryProgress &&
+			i+1 < len(entries) && entries[i+1].Type == woodpecker.LogEntryProgress {
+			continue
+		}
+
+		data := strings.TrimRight(string(entry.Data), "\r\n")
+		if opts.CollapseProgress {
+			data = collapseCarriageReturns(data)
+		}
+		if opts.StripANSI {
+			data = stripANSI(data)
+		}
+
+		normalized = append(normalized, logEntry{
+			Line: int(entry.Line),
+			Time: entry.Time,
+			Type: logEntryTypeName(entry.Type),
+			Data: data,
+		})
+	}
+	return normalized
+}
+
+func stripANSI(s string) string {
+	if !strings.ContainsRune(s, '\x1b') {
+		return s
+	}
+	return ansiEscape.ReplaceAllString(s, "")
+}
+
+// collapseCarriageReturns keeps what a terminal would finally show for each line
+// when progress output rewrites it with \r
+func collapseCarriageReturns(s string) string {
+	if !strings.ContainsRune(s, '\r') {
+		return s
+	}
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if idx := strings.LastIndexByte(line, '\r'); idx >= 0 {
+			line = line[idx+1:]
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// decodeLogLines returns the cleaned output lines of a step log, skipping empty
+// entries and exit code and metadata markers
+func decodeLogLines(entries []*woodpecker.LogEntry) []logLine {
+	lines := make([]logLine, 0, len(entries))
+	for _, entry := range normalizeLogEntries(entries, logOptions{StripANSI: true, CollapseProgress: true}) {
+		if entry.Data == "" || entry.Type == "exit_code" || entry.Type == "metadata" {
+			continue
+		}
+		lines = append(lines, logLine{Number: entry.Line, Text: entry.Data})
+	}
+	return lines
+}
+
+// tailLines returns the last n lines, or all of them when n <= 0
+func tailLines(lines []logLine, n int) []logLine {
+	if n <= 0 || n >= len(lines) {
+		return lines
+	}
+	return lines[len(lines)-n:]
+}
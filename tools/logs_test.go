@@ -0,0 +1,43 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+func TestStripANSI(t *testing.T) {
+	require.Equal(t, "PASS ok", stripANSI("\x1b[32mPASS\x1b[0m \x1b[1;4mok\x1b[m"))
+	require.Equal(t, "link", stripANSI("\x1b]8;;https://example.com\x07link\x1b]8;;\x07"))
+	require.Equal(t, "plain", stripANSI("plain"))
+}
+
+func TestCollapseCarriageReturns(t *testing.T) {
+	require.Equal(t, "100% done", collapseCarriageReturns("10%\r50%\r100% done"))
+	require.Equal(t, "a\nb", collapseCarriageReturns("x\ra\r\nb"))
+	require.Equal(t, "plain", collapseCarriageReturns("plain"))
+}
+
+func TestNormalizeLogEntries(t *testing.T) {
+	entries := []*woodpecker.LogEntry{
+		{Line: 0, Time: 0, Type: woodpecker.LogEntryStdout, Data: []byte("\x1b[1mStep\x1b[0m\n")},
+		{Line: 1, Time: 2, Type: woodpecker.LogEntryProgress, Data: []byte("10%")},
+		{Line: 2, Time: 3, Type: woodpecker.LogEntryProgress, Data: []byte("60%")},
+		{Line: 3, Time: 4, Type: woodpecker.LogEntryProgress, Data: []byte("100%")},
+		{Line: 4, Time: 9, Type: woodpecker.LogEntryExitCode, Data: []byte("1")},
+	}
+
+	normalized := normalizeLogEntries(entries, logOptions{StripANSI: true, CollapseProgress: true})
+	require.Equal(t, []logEntry{
+		{Line: 0, Time: 0, Type: "stdout", Data: "Step"},
+		{Line: 3, Time: 4, Type: "progress", Data: "100%"},
+		{Line: 4, Time: 9, Type: "exit_code", Data: "1"},
+	}, normalized)
+
+	raw := normalizeLogEntries(entries, logOptions{})
+	require.Len(t, raw, 5)
+	require.Equal(t, "\x1b[1mStep\x1b[0m", raw[0].Data)
+
+	require.Equal(t, []logLine{{Number: 0, Text: "Step"}, {Number: 3, Text: "100%"}}, decodeLogLines(entries))
+}
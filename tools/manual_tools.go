@@ -2,11 +2,11 @@ package tools
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -16,15 +16,27 @@ import (
 	"go.woodpecker-ci.org/woodpecker/v3/pipeline/frontend/yaml/linter"
 	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
 
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/audit"
 	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/policy"
 )
 
 type ToolManager struct {
-	client *client.Client
-	logger *logrus.Logger
-	tools  []mcp.Tool
+	client   *client.Client
+	logger   *logrus.Logger
+	tools    []mcp.Tool
+	resolver ClientResolver
+	filter   ToolFilter
+	policy   *policy.Policy
+	confirm  *confirmations
+	audit    *audit.Logger
+	redactor *Redactor
+	shape    shapeOptions
 }
 
+// ClientResolver picks the Woodpecker client for a tool call, e.g. from the caller's token
+type ClientResolver func(ctx context.Context) (*client.Client, error)
+
 func NewToolManager(wclient *client.Client, logger *logrus.Logger) *ToolManager {
 	tm := &ToolManager{
 		client: wclient,
@@ -35,24 +47,677 @@ func NewToolManager(wclient *client.Client, logger *logrus.Logger) *ToolManager
 	return tm
 }
 
+// SetClientResolver makes every tool call use the client returned by resolver
+// instead of the shared client passed to NewToolManager
+func (tm *ToolManager) SetClientResolver(resolver ClientResolver) {
+	tm.resolver = resolver
+}
+
+// forRequest returns a ToolManager bound to the client for this call
+func (tm *ToolManager) forRequest(ctx context.Context) (*ToolManager, error) {
+	if tm.resolver == nil {
+		return tm, nil
+	}
+
+	wclient, err := tm.resolver(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	scoped := *tm
+	scoped.client = wclient
+	return &scoped, nil
+}
+
 func (tm *ToolManager) initializeTools() {
 	tm.tools = []mcp.Tool{
 		{
-			Name:        "list_repositories",
-			Description: "List all repositories accessible to the authenticated user",
+			Name:        "list_repositories",
+			Description: "List all repositories accessible to the authenticated user",
+			Annotations: readOnlyTool(),
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"all": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Include all repositories (default: false, only active repositories)",
+					},
+				},
+			},
+		},
+		{
+			Name:        "get_repository",
+			Description: "Get detailed information about a specific repository",
+			Annotations: readOnlyTool(),
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"repo_id": map[string]interface{}{
+						"type":        "number",
+						"description": "Repository ID (optional, can use repo_name or infer from git remote)",
+					},
+					"repo_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
+					},
+				},
+			},
+		},
+		{
+			Name:        "list_pipelines",
+			Description: "List pipelines for a repository",
+			Annotations: readOnlyTool(),
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"repo_id": map[string]interface{}{
+						"type":        "number",
+						"description": "Repository ID (optional, can use repo_name or infer from git remote)",
+					},
+					"repo_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
+					},
+					"limit": map[string]interface{}{
+						"type":        "number",
+						"description": "Maximum number of pipelines to return; pages are fetched until this is satisfied (default: 10, max: 100)",
+					},
+					"page": map[string]interface{}{
+						"type":        "number",
+						"description": "Page to start from, as returned in next_cursor (default: 1)",
+					},
+					"per_page": map[string]interface{}{
+						"type":        "number",
+						"description": "Page size requested from Woodpecker (default: 25, max: 50)",
+					},
+					"offset": map[string]interface{}{
+						"type":        "number",
+						"description": "Number of pipelines to skip on the starting page, as returned in next_cursor (default: 0)",
+					},
+					"branch": map[string]interface{}{
+						"type":        "string",
+						"description": "Only return pipelines for this branch",
+					},
+					"event": map[string]interface{}{
+						"type":        "string",
+						"description": "Only return pipelines for these events, comma-separated (push, pull_request, tag, deployment, cron, manual)",
+					},
+					"status": map[string]interface{}{
+						"type":        "string",
+						"description": "Only return pipelines with this status (e.g. success, failure, running, pending)",
+					},
+					"before": map[string]interface{}{
+						"type":        "string",
+						"description": "Only return pipelines created before this time (RFC 3339 or unix seconds)",
+					},
+					"after": map[string]interface{}{
+						"type":        "string",
+						"description": "Only return pipelines created after this time (RFC 3339 or unix seconds)",
+					},
+				},
+			},
+		},
+		{
+			Name:        "get_pipeline_status",
+			Description: "Get the status of a specific pipeline",
+			Annotations: readOnlyTool(),
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"repo_id": map[string]interface{}{
+						"type":        "number",
+						"description": "Repository ID (optional, can use repo_name or infer from git remote)",
+					},
+					"repo_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
+					},
+					"pipeline_number": map[string]interface{}{
+						"type":        "number",
+						"description": "Pipeline number (required if not using 'latest')",
+					},
+					"latest": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Get the latest pipeline (default: false)",
+					},
+				},
+			},
+		},
+		{
+			Name:        "get_pipeline_steps",
+			Description: "Get the workflows and steps of a pipeline as a tree with step IDs, states, exit codes and durations, to find the step_id for get_logs",
+			Annotations: readOnlyTool(),
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"repo_id": map[string]interface{}{
+						"type":        "number",
+						"description": "Repository ID (optional, can use repo_name or infer from git remote)",
+					},
+					"repo_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
+					},
+					"pipeline_number": map[string]interface{}{
+						"type":        "number",
+						"description": "Pipeline number (required if not using 'latest')",
+					},
+					"latest": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Use the latest pipeline (default: false)",
+					},
+				},
+			},
+		},
+		{
+			Name:        "diagnose_pipeline",
+			Description: "Explain why a pipeline failed: lists every failed step with log excerpts around the first error markers, the log tail, and the commit, author and branch",
+			Annotations: readOnlyTool(),
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"repo_id": map[string]interface{}{
+						"type":        "number",
+						"description": "Repository ID (optional, can use repo_name or infer from git remote)",
+					},
+					"repo_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
+					},
+					"pipeline_number": map[string]interface{}{
+						"type":        "number",
+						"description": "Pipeline number (required if not using 'latest')",
+					},
+					"latest": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Diagnose the latest pipeline (default: false)",
+					},
+					"tail_lines": map[string]interface{}{
+						"type":        "number",
+						"description": "Log lines to include from the end of each failed step (default: 40, max: 500, 0 for none)",
+					},
+				},
+			},
+		},
+		{
+			Name:        "wait_for_pipeline",
+			Description: "Wait until a pipeline finishes or a timeout elapses, reporting state changes as progress notifications, and return the final status with the failed steps",
+			Annotations: readOnlyTool(),
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"repo_id": map[string]interface{}{
+						"type":        "number",
+						"description": "Repository ID (optional, can use repo_name or infer from git remote)",
+					},
+					"repo_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
+					},
+					"pipeline_number": map[string]interface{}{
+						"type":        "number",
+						"description": "Pipeline number (required if not using 'latest')",
+					},
+					"latest": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Wait for the latest pipeline (default: false)",
+					},
+					"timeout_seconds": map[string]interface{}{
+						"type":        "number",
+						"description": "Give up waiting after this many seconds (default: 300, max: 1800)",
+					},
+				},
+			},
+		},
+		{
+			Name:        "start_pipeline",
+			Description: "Start (restart) a specific pipeline",
+			Annotations: mutatingTool(false),
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"repo_id": map[string]interface{}{
+						"type":        "number",
+						"description": "Repository ID (optional, can use repo_name or infer from git remote)",
+					},
+					"repo_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
+					},
+					"pipeline_number": map[string]interface{}{
+						"type":        "number",
+						"description": "Pipeline number to restart",
+					},
+					"fork": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Fork the pipeline (default: false)",
+					},
+				},
+			},
+		},
+		{
+			Name:        "stop_pipeline",
+			Description: "Stop a running pipeline",
+			Annotations: mutatingTool(true),
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"repo_id": map[string]interface{}{
+						"type":        "number",
+						"description": "Repository ID (optional, can use repo_name or infer from git remote)",
+					},
+					"repo_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
+					},
+					"pipeline_number": map[string]interface{}{
+						"type":        "number",
+						"description": "Pipeline number to stop",
+					},
+				},
+			},
+		},
+		{
+			Name:        "approve_pipeline",
+			Description: "Approve a pending pipeline",
+			Annotations: mutatingTool(false),
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"repo_id": map[string]interface{}{
+						"type":        "number",
+						"description": "Repository ID (optional, can use repo_name or infer from git remote)",
+					},
+					"repo_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
+					},
+					"pipeline_number": map[string]interface{}{
+						"type":        "number",
+						"description": "Pipeline number to approve",
+					},
+				},
+			},
+		},
+		{
+			Name:        "trigger_pipeline",
+			Description: "Trigger a new manual pipeline for a branch of a repository, optionally with variables, and return it with a link to the web UI. It runs the branch's latest commit as a manual event; Woodpecker's API does not accept a commit or event to run",
+			Annotations: mutatingTool(false),
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"repo_id": map[string]interface{}{
+						"type":        "number",
+						"description": "Repository ID (optional, can use repo_name or infer from git remote)",
+					},
+					"repo_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
+					},
+					"branch": map[string]interface{}{
+						"type":        "string",
+						"description": "Branch to trigger pipeline for; must exist in the repository (default: the repository's default branch)",
+					},
+					"variables": map[string]interface{}{
+						"type":                 "object",
+						"description":          "Pipeline variables passed to the manual pipeline, as string key-value pairs",
+						"additionalProperties": map[string]interface{}{"type": "string"},
+					},
+				},
+			},
+		},
+		{
+			Name:        "get_logs",
+			Description: "Get logs for a specific pipeline step",
+			Annotations: readOnlyTool(),
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"repo_id": map[string]interface{}{
+						"type":        "number",
+						"description": "Repository ID (optional, can use repo_name or infer from git remote)",
+					},
+					"repo_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
+					},
+					"pipeline_number": map[string]interface{}{
+						"type":        "number",
+						"description": "Pipeline number",
+					},
+					"step_id": map[string]interface{}{
+						"type":        "number",
+						"description": "Step ID to get logs for (optional if step_name is given)",
+					},
+					"step_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Step name to get logs for, resolved against the pipeline's workflows (alternative to step_id)",
+					},
+					"workflow_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Workflow containing step_name, needed when several workflows have a step with that name",
+					},
+					"format": map[string]interface{}{
+						"type":        "string",
+						"description": "Output format: 'json' for structured data or 'text' for plain text (default: json)",
+					},
+					"lines": map[string]interface{}{
+						"type":        "number",
+						"description": "Number of lines to return (default: all)",
+					},
+					"tail": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Return last N lines instead of first N (default: false for head)",
+					},
+					"strip_ansi": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Remove terminal colour and control codes (default: true)",
+					},
+					"collapse_progress": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Keep only the final state of carriage-return progress lines and runs of progress entries (default: true)",
+					},
+					"timestamps": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Prefix text lines with seconds since step start; JSON entries always carry time (default: false)",
+					},
+					"include_types": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Include exit code and metadata entries in text output; JSON entries always carry type (stdout, exit_code, metadata, progress). Woodpecker merges stderr into stdout (default: false)",
+					},
+				},
+			},
+		},
+		{
+			Name:        "search_logs",
+			Description: "Search the logs of a step, a workflow or a whole pipeline with a regular expression and return matching lines with context. Steps whose logs cannot be fetched are listed under errors",
+			Annotations: readOnlyTool(),
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"repo_id": map[string]interface{}{
+						"type":        "number",
+						"description": "Repository ID (optional, can use repo_name or infer from git remote)",
+					},
+					"repo_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
+					},
+					"pipeline_number": map[string]interface{}{
+						"type":        "number",
+						"description": "Pipeline number (required if not using 'latest')",
+					},
+					"latest": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Search the latest pipeline (default: false)",
+					},
+					"pattern": map[string]interface{}{
+						"type":        "string",
+						"description": "Regular expression (RE2 syntax) matched against each log line",
+					},
+					"case_insensitive": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Match case-insensitively (default: false)",
+					},
+					"step_id": map[string]interface{}{
+						"type":        "number",
+						"description": "Only search this step",
+					},
+					"step_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Only search the step with this name",
+					},
+					"workflow_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Only search steps of this workflow (default: whole pipeline)",
+					},
+					"before": map[string]interface{}{
+						"type":        "number",
+						"description": "Context lines before each match (default: 2, max: 20)",
+					},
+					"after": map[string]interface{}{
+						"type":        "number",
+						"description": "Context lines after each match (default: 2, max: 20)",
+					},
+					"max_matches": map[string]interface{}{
+						"type":        "number",
+						"description": "Stop after this many matches (default: 50, max: 500)",
+					},
+					"max_match_bytes": map[string]interface{}{
+						"type":        "number",
+						"description": "Stop once matched lines and context reach this many bytes (default: 16384, max: 65536)",
+					},
+				},
+				Required: []string{"pattern"},
+			},
+		},
+		{
+			Name:        "follow_logs",
+			Description: "Follow the live log of a running step, forwarding new lines as progress or log notifications, until the step finishes or the timeout elapses",
+			Annotations: readOnlyTool(),
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"repo_id": map[string]interface{}{
+						"type":        "number",
+						"description": "Repository ID (optional, can use repo_name or infer from git remote)",
+					},
+					"repo_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
+					},
+					"pipeline_number": map[string]interface{}{
+						"type":        "number",
+						"description": "Pipeline number",
+					},
+					"step_id": map[string]interface{}{
+						"type":        "number",
+						"description": "Step ID to follow (optional if step_name is given)",
+					},
+					"step_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Step name to follow, resolved against the pipeline's workflows (alternative to step_id)",
+					},
+					"workflow_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Workflow containing step_name, needed when several workflows have a step with that name",
+					},
+					"timeout_seconds": map[string]interface{}{
+						"type":        "number",
+						"description": "Stop following after this many seconds (default: 60, max: 600)",
+					},
+					"after_line": map[string]interface{}{
+						"type":        "number",
+						"description": "Skip lines up to and including this line number, e.g. last_line from a previous call (default: none)",
+					},
+					"lines": map[string]interface{}{
+						"type":        "number",
+						"description": "Number of most recent lines to return when following stops (default: 100, max: 1000)",
+					},
+					"strip_ansi": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Remove terminal colour and control codes (default: true)",
+					},
+				},
+			},
+		},
+		{
+			Name:        "list_secrets",
+			Description: "List the secrets a repository's pipelines can use, from the repository, its organization and the server, showing which level each name resolves from; values are never returned",
+			Annotations: readOnlyTool(),
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"repo_id": map[string]interface{}{
+						"type":        "number",
+						"description": "Repository ID (optional, can use repo_name or infer from git remote)",
+					},
+					"repo_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
+					},
+					"scope": map[string]interface{}{
+						"type":        "string",
+						"description": "Which secrets to list: repo, org, global, or all levels with the definition each name resolves to (default: all)",
+					},
+					"org": map[string]interface{}{
+						"type":        "string",
+						"description": "Organization for scope org (default: the owner of the repository)",
+					},
+				},
+			},
+		},
+		{
+			Name:        "create_secret",
+			Description: "Create a repository, organization or global secret; the value is write-only and is never returned or logged",
+			Annotations: mutatingTool(false),
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"repo_id": map[string]interface{}{
+						"type":        "number",
+						"description": "Repository ID (optional, can use repo_name or infer from git remote)",
+					},
+					"repo_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
+					},
+					"scope": map[string]interface{}{
+						"type":        "string",
+						"description": "Where to create the secret: repo, org or global (global needs an admin token; default: repo)",
+					},
+					"org": map[string]interface{}{
+						"type":        "string",
+						"description": "Organization for scope org (default: the owner of the repository)",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Secret name, as referenced by from_secret in the pipeline",
+					},
+					"value": map[string]interface{}{
+						"type":        "string",
+						"description": "Secret value",
+					},
+					"events": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Events the secret is available to: push, tag, pull_request, pull_request_closed, deployment, cron, manual, release (default: all but pull requests)",
+					},
+					"images": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Only expose the secret to steps using these images (default: any image)",
+					},
+				},
+				Required: []string{"name", "value"},
+			},
+		},
+		{
+			Name:        "update_secret",
+			Description: "Update a repository, organization or global secret's value, events or images; omitted fields are unchanged",
+			Annotations: mutatingTool(true),
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"repo_id": map[string]interface{}{
+						"type":        "number",
+						"description": "Repository ID (optional, can use repo_name or infer from git remote)",
+					},
+					"repo_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
+					},
+					"scope": map[string]interface{}{
+						"type":        "string",
+						"description": "Where the secret lives: repo, org or global (default: repo)",
+					},
+					"org": map[string]interface{}{
+						"type":        "string",
+						"description": "Organization for scope org (default: the owner of the repository)",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Secret name, as referenced by from_secret in the pipeline",
+					},
+					"value": map[string]interface{}{
+						"type":        "string",
+						"description": "New secret value (optional)",
+					},
+					"events": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Replace the events the secret is available to (optional)",
+					},
+					"images": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Replace the images the secret is restricted to; an empty list allows any image (optional)",
+					},
+				},
+				Required: []string{"name"},
+			},
+		},
+		{
+			Name:        "delete_secret",
+			Description: "Delete a repository, organization or global secret",
+			Annotations: mutatingTool(true),
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"repo_id": map[string]interface{}{
+						"type":        "number",
+						"description": "Repository ID (optional, can use repo_name or infer from git remote)",
+					},
+					"repo_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
+					},
+					"scope": map[string]interface{}{
+						"type":        "string",
+						"description": "Where the secret lives: repo, org or global (default: repo)",
+					},
+					"org": map[string]interface{}{
+						"type":        "string",
+						"description": "Organization for scope org (default: the owner of the repository)",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Name of the secret to delete",
+					},
+				},
+				Required: []string{"name"},
+			},
+		},
+		{
+			Name:        "list_registries",
+			Description: "List the container registry credentials a repository's pipelines can use, from the repository and its organization; passwords are never returned",
+			Annotations: readOnlyTool(),
 			InputSchema: mcp.ToolInputSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
-					"all": map[string]interface{}{
-						"type":        "boolean",
-						"description": "Include all repositories (default: false, only active repositories)",
+					"repo_id": map[string]interface{}{
+						"type":        "number",
+						"description": "Repository ID (optional, can use repo_name or infer from git remote)",
+					},
+					"repo_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
+					},
+					"scope": map[string]interface{}{
+						"type":        "string",
+						"description": "Which credentials to list: repo, org, or both levels with the credentials each registry resolves to (default: all)",
+					},
+					"org": map[string]interface{}{
+						"type":        "string",
+						"description": "Organization for scope org (default: the owner of the repository)",
 					},
 				},
 			},
 		},
 		{
-			Name:        "get_repository",
-			Description: "Get detailed information about a specific repository",
+			Name:        "create_registry",
+			Description: "Add repository or organization credentials for pulling images from a private container registry; the password is write-only and is never returned or logged",
+			Annotations: mutatingTool(false),
 			InputSchema: mcp.ToolInputSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
@@ -64,12 +729,34 @@ func (tm *ToolManager) initializeTools() {
 						"type":        "string",
 						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
 					},
+					"scope": map[string]interface{}{
+						"type":        "string",
+						"description": "Where to add the credentials: repo or org (default: repo)",
+					},
+					"org": map[string]interface{}{
+						"type":        "string",
+						"description": "Organization for scope org (default: the owner of the repository)",
+					},
+					"address": map[string]interface{}{
+						"type":        "string",
+						"description": "Registry host, e.g. ghcr.io or docker.io",
+					},
+					"username": map[string]interface{}{
+						"type":        "string",
+						"description": "Registry username",
+					},
+					"password": map[string]interface{}{
+						"type":        "string",
+						"description": "Registry password or access token",
+					},
 				},
+				Required: []string{"address", "username", "password"},
 			},
 		},
 		{
-			Name:        "list_pipelines",
-			Description: "List pipelines for a repository",
+			Name:        "update_registry",
+			Description: "Replace the username or password stored for a container registry, e.g. after the credentials expired; omitted fields are unchanged",
+			Annotations: mutatingTool(true),
 			InputSchema: mcp.ToolInputSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
@@ -81,16 +768,34 @@ func (tm *ToolManager) initializeTools() {
 						"type":        "string",
 						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
 					},
-					"limit": map[string]interface{}{
-						"type":        "number",
-						"description": "Maximum number of pipelines to return (default: 10, max: 100)",
+					"scope": map[string]interface{}{
+						"type":        "string",
+						"description": "Where the credentials live: repo or org (default: repo)",
+					},
+					"org": map[string]interface{}{
+						"type":        "string",
+						"description": "Organization for scope org (default: the owner of the repository)",
+					},
+					"address": map[string]interface{}{
+						"type":        "string",
+						"description": "Registry host, e.g. ghcr.io or docker.io",
+					},
+					"username": map[string]interface{}{
+						"type":        "string",
+						"description": "New registry username (optional)",
+					},
+					"password": map[string]interface{}{
+						"type":        "string",
+						"description": "New registry password or access token (optional)",
 					},
 				},
+				Required: []string{"address"},
 			},
 		},
 		{
-			Name:        "get_pipeline_status",
-			Description: "Get the status of a specific pipeline",
+			Name:        "delete_registry",
+			Description: "Remove the credentials stored for a container registry",
+			Annotations: mutatingTool(true),
 			InputSchema: mcp.ToolInputSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
@@ -102,20 +807,26 @@ func (tm *ToolManager) initializeTools() {
 						"type":        "string",
 						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
 					},
-					"pipeline_number": map[string]interface{}{
-						"type":        "number",
-						"description": "Pipeline number (required if not using 'latest')",
+					"scope": map[string]interface{}{
+						"type":        "string",
+						"description": "Where the credentials live: repo or org (default: repo)",
 					},
-					"latest": map[string]interface{}{
-						"type":        "boolean",
-						"description": "Get the latest pipeline (default: false)",
+					"org": map[string]interface{}{
+						"type":        "string",
+						"description": "Organization for scope org (default: the owner of the repository)",
+					},
+					"address": map[string]interface{}{
+						"type":        "string",
+						"description": "Registry host whose credentials to remove",
 					},
 				},
+				Required: []string{"address"},
 			},
 		},
 		{
-			Name:        "start_pipeline",
-			Description: "Start (restart) a specific pipeline",
+			Name:        "check_registry_images",
+			Description: "Cross-reference the images used in local pipeline config files against the registry credentials configured for the repository and its organization",
+			Annotations: readOnlyTool(),
 			InputSchema: mcp.ToolInputSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
@@ -127,20 +838,43 @@ func (tm *ToolManager) initializeTools() {
 						"type":        "string",
 						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
 					},
-					"pipeline_number": map[string]interface{}{
+					"org": map[string]interface{}{
+						"type":        "string",
+						"description": "Organization for scope org (default: the owner of the repository)",
+					},
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Workflow file or directory of workflow files (default: .woodpecker/, .woodpecker.yaml or .woodpecker.yml in the working directory)",
+					},
+				},
+			},
+		},
+		{
+			Name:        "list_crons",
+			Description: "List a repository's cron jobs with the next times each is scheduled to run",
+			Annotations: readOnlyTool(),
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"repo_id": map[string]interface{}{
 						"type":        "number",
-						"description": "Pipeline number to restart",
+						"description": "Repository ID (optional, can use repo_name or infer from git remote)",
 					},
-					"fork": map[string]interface{}{
-						"type":        "boolean",
-						"description": "Fork the pipeline (default: false)",
+					"repo_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
+					},
+					"next_runs": map[string]interface{}{
+						"type":        "number",
+						"description": "Number of upcoming run times to compute from the schedule, in UTC (default: 5, max: 50)",
 					},
 				},
 			},
 		},
 		{
-			Name:        "stop_pipeline",
-			Description: "Stop a running pipeline",
+			Name:        "get_cron",
+			Description: "Get a cron job with the next times it is scheduled to run",
+			Annotations: readOnlyTool(),
 			InputSchema: mcp.ToolInputSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
@@ -152,16 +886,22 @@ func (tm *ToolManager) initializeTools() {
 						"type":        "string",
 						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
 					},
-					"pipeline_number": map[string]interface{}{
+					"cron_id": map[string]interface{}{
 						"type":        "number",
-						"description": "Pipeline number to stop",
+						"description": "Cron job ID",
+					},
+					"next_runs": map[string]interface{}{
+						"type":        "number",
+						"description": "Number of upcoming run times to compute from the schedule, in UTC (default: 5, max: 50)",
 					},
 				},
+				Required: []string{"cron_id"},
 			},
 		},
 		{
-			Name:        "approve_pipeline",
-			Description: "Approve a pending pipeline",
+			Name:        "create_cron",
+			Description: "Create a cron job that runs the repository's pipeline on a schedule; the schedule is validated and the next run times are returned",
+			Annotations: mutatingTool(false),
 			InputSchema: mcp.ToolInputSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
@@ -173,16 +913,30 @@ func (tm *ToolManager) initializeTools() {
 						"type":        "string",
 						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
 					},
-					"pipeline_number": map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Cron job name, available to the pipeline as CI_PIPELINE_CRON",
+					},
+					"schedule": map[string]interface{}{
+						"type":        "string",
+						"description": "Five fields (minute hour day-of-month month day-of-week, e.g. 0 3 * * 1-5), a descriptor (@hourly, @daily, @weekly, @monthly, @yearly) or @every <duration>; prefix CRON_TZ=<zone> for a time zone other than UTC",
+					},
+					"branch": map[string]interface{}{
+						"type":        "string",
+						"description": "Branch to run on (default: the repository's default branch)",
+					},
+					"next_runs": map[string]interface{}{
 						"type":        "number",
-						"description": "Pipeline number to approve",
+						"description": "Number of upcoming run times to compute from the schedule, in UTC (default: 5, max: 50)",
 					},
 				},
+				Required: []string{"name", "schedule"},
 			},
 		},
 		{
-			Name:        "trigger_pipeline",
-			Description: "Trigger a new pipeline for a repository",
+			Name:        "update_cron",
+			Description: "Change a cron job's name, schedule or branch; omitted fields are unchanged",
+			Annotations: mutatingTool(true),
 			InputSchema: mcp.ToolInputSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
@@ -194,16 +948,34 @@ func (tm *ToolManager) initializeTools() {
 						"type":        "string",
 						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
 					},
+					"cron_id": map[string]interface{}{
+						"type":        "number",
+						"description": "Cron job ID",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "New cron job name (optional)",
+					},
+					"schedule": map[string]interface{}{
+						"type":        "string",
+						"description": "New schedule (optional): five fields (minute hour day-of-month month day-of-week, e.g. 0 3 * * 1-5), a descriptor (@hourly, @daily, @weekly, @monthly, @yearly) or @every <duration>; prefix CRON_TZ=<zone> for a time zone other than UTC",
+					},
 					"branch": map[string]interface{}{
 						"type":        "string",
-						"description": "Branch to trigger pipeline for (default: main branch)",
+						"description": "New branch to run on (optional)",
+					},
+					"next_runs": map[string]interface{}{
+						"type":        "number",
+						"description": "Number of upcoming run times to compute from the schedule, in UTC (default: 5, max: 50)",
 					},
 				},
+				Required: []string{"cron_id"},
 			},
 		},
 		{
-			Name:        "get_logs",
-			Description: "Get logs for a specific pipeline step",
+			Name:        "delete_cron",
+			Description: "Delete a cron job",
+			Annotations: mutatingTool(true),
 			InputSchema: mcp.ToolInputSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
@@ -215,25 +987,101 @@ func (tm *ToolManager) initializeTools() {
 						"type":        "string",
 						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
 					},
-					"pipeline_number": map[string]interface{}{
+					"cron_id": map[string]interface{}{
 						"type":        "number",
-						"description": "Pipeline number",
+						"description": "ID of the cron job to delete",
 					},
-					"step_id": map[string]interface{}{
+				},
+				Required: []string{"cron_id"},
+			},
+		},
+		{
+			Name:        "run_cron_now",
+			Description: "Start a cron job's pipeline right away, outside its schedule",
+			Annotations: mutatingTool(false),
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"repo_id": map[string]interface{}{
 						"type":        "number",
-						"description": "Step ID to get logs for",
+						"description": "Repository ID (optional, can use repo_name or infer from git remote)",
 					},
-					"format": map[string]interface{}{
+					"repo_name": map[string]interface{}{
 						"type":        "string",
-						"description": "Output format: 'json' for structured data or 'text' for plain text (default: json)",
+						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
 					},
-					"lines": map[string]interface{}{
+					"cron_id": map[string]interface{}{
 						"type":        "number",
-						"description": "Number of lines to return (default: all)",
+						"description": "Cron job ID",
 					},
-					"tail": map[string]interface{}{
+				},
+				Required: []string{"cron_id"},
+			},
+		},
+		{
+			Name:        "list_agents",
+			Description: "List the server's agents with their platform, labels, capacity, last contact and number of running workflows (needs an admin token)",
+			Annotations: readOnlyTool(),
+			InputSchema: mcp.ToolInputSchema{
+				Type:       "object",
+				Properties: map[string]interface{}{},
+			},
+		},
+		{
+			Name:        "get_agent",
+			Description: "Get an agent's platform, labels, capacity and last contact with the workflows it is running (needs an admin token)",
+			Annotations: readOnlyTool(),
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"agent_id": map[string]interface{}{
+						"type":        "number",
+						"description": "Agent ID",
+					},
+				},
+				Required: []string{"agent_id"},
+			},
+		},
+		{
+			Name:        "get_queue_info",
+			Description: "Show the server's queue: pending, dependency-blocked and running workflows with their labels, agents and how long they have waited (needs an admin token)",
+			Annotations: readOnlyTool(),
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"limit": map[string]interface{}{
+						"type":        "number",
+						"description": "Maximum workflows listed per queue state (default: 50, max: 500)",
+					},
+				},
+			},
+		},
+		{
+			Name:        "explain_pending_workflow",
+			Description: "Explain why a pipeline's pending workflows have not started: matches each workflow's labels against every agent and checks whether matching agents are paused, offline or at capacity (needs an admin token)",
+			Annotations: readOnlyTool(),
+			InputSchema: mcp.ToolInputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"repo_id": map[string]interface{}{
+						"type":        "number",
+						"description": "Repository ID (optional, can use repo_name or infer from git remote)",
+					},
+					"repo_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Repository full name (optional, owner/repo, can use repo_id or infer from git remote)",
+					},
+					"pipeline_number": map[string]interface{}{
+						"type":        "number",
+						"description": "Pipeline number (required if not using 'latest')",
+					},
+					"latest": map[string]interface{}{
 						"type":        "boolean",
-						"description": "Return last N lines instead of first N (default: false for head)",
+						"description": "Use the latest pipeline (default: false)",
+					},
+					"workflow_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Only explain this workflow (default: every pending workflow of the pipeline)",
 					},
 				},
 			},
@@ -241,6 +1089,7 @@ func (tm *ToolManager) initializeTools() {
 		{
 			Name:        "lint_config",
 			Description: "Lint a Woodpecker CI pipeline configuration file (local YAML file)",
+			Annotations: readOnlyTool(),
 			InputSchema: mcp.ToolInputSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
@@ -259,70 +1108,161 @@ func (tm *ToolManager) initializeTools() {
 	}
 }
 
+// SetToolFilter restricts which tools GetServerTools exposes
+func (tm *ToolManager) SetToolFilter(filter ToolFilter) {
+	tm.filter = filter
+}
+
 func (tm *ToolManager) GetServerTools() []server.ServerTool {
 	var serverTools []server.ServerTool
 	for _, tool := range tm.tools {
+		if !tm.filter.Allows(tool) {
+			tm.logger.WithField("tool", tool.Name).Debug("Tool disabled by configuration")
+			continue
+		}
+		exposed := withShapeArgs(tool)
+		if tm.confirm.requires(tool) {
+			exposed = withConfirmTokenArg(exposed)
+		}
 		serverTools = append(serverTools, server.ServerTool{
-			Tool:    tool,
-			Handler: tm.getToolHandler(tool.Name),
+			Tool:    exposed,
+			Handler: tm.getToolHandler(tool),
 		})
 	}
 	return serverTools
 }
 
-func (tm *ToolManager) getToolHandler(name string) server.ToolHandlerFunc {
+func (tm *ToolManager) getToolHandler(tool mcp.Tool) server.ToolHandlerFunc {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		tm.logger.WithFields(logrus.Fields{
-			"tool":      name,
-			"arguments": request.Params.Arguments,
-		}).Debug("Calling tool")
+		start := time.Now()
+		record := audit.Record{Time: start, Tool: tool.Name}
 
 		// Type assert arguments to map[string]interface{}
 		arguments, ok := request.Params.Arguments.(map[string]interface{})
 		if !ok {
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.TextContent{
-						Type: "text",
-						Text: "Invalid arguments format",
-					},
-				},
-				IsError: true,
-			}, nil
+			result := tm.errorResult(client.Validationf("invalid arguments format"))
+			tm.recordAudit(record, result, nil)
+			return result, nil
 		}
 
-		switch name {
-		case "list_repositories":
-			return tm.handleListRepositories(ctx, arguments)
-		case "get_repository":
-			return tm.handleGetRepository(ctx, arguments)
-		case "list_pipelines":
-			return tm.handleListPipelines(ctx, arguments)
-		case "get_pipeline_status":
-			return tm.handleGetPipelineStatus(ctx, arguments)
-		case "start_pipeline":
-			return tm.handleStartPipeline(ctx, arguments)
-		case "stop_pipeline":
-			return tm.handleStopPipeline(ctx, arguments)
-		case "approve_pipeline":
-			return tm.handleApprovePipeline(ctx, arguments)
-		case "trigger_pipeline":
-			return tm.handleTriggerPipeline(ctx, arguments)
-		case "get_logs":
-			return tm.handleGetLogs(ctx, arguments)
-		case "lint_config":
-			return tm.handleLintConfig(ctx, arguments)
-		default:
-			return &mcp.CallToolResult{
-				Content: []mcp.Content{
-					mcp.TextContent{
-						Type: "text",
-						Text: fmt.Sprintf("Unknown tool: %s", name),
-					},
-				},
-				IsError: true,
-			}, nil
+		// Snapshot before the call, since resolving the target adds repo_id.
+		// Logged sanitized too, as arguments can carry secret values.
+		record.Arguments = audit.SanitizeArguments(arguments)
+		tm.logger.WithFields(logrus.Fields{
+			"tool":      tool.Name,
+			"arguments": record.Arguments,
+		}).Debug("Calling tool")
+
+		ctx = withProgressToken(ctx, request.Params.Meta)
+		result, err := tm.callTool(ctx, tool, arguments, &record)
+		if repoID, ok := arguments["repo_id"].(float64); ok {
+			record.RepoID = int64(repoID)
 		}
+		tm.recordAudit(record, result, err)
+		return result, err
+	}
+}
+
+// callTool checks the call against policy and confirmation and dispatches it to
+// its handler, noting the caller and any early outcome in record
+func (tm *ToolManager) callTool(ctx context.Context, tool mcp.Tool, arguments map[string]interface{}, record *audit.Record) (*mcp.CallToolResult, error) {
+	scoped, err := tm.forRequest(ctx)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	shape, err := getShapeOptions(arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+	scoped = scoped.shapedFor(shape)
+
+	if scoped.client != nil {
+		record.User = scoped.client.Login()
+	}
+
+	if err := scoped.enforcePolicy(ctx, tool, arguments); err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	if confirmation, err := scoped.requireConfirmation(ctx, tool, arguments); err != nil {
+		return tm.errorResult(err), nil
+	} else if confirmation != nil {
+		record.Outcome = audit.OutcomeConfirmationRequired
+		return confirmation, nil
+	}
+
+	switch tool.Name {
+	case "list_repositories":
+		return scoped.handleListRepositories(ctx, arguments)
+	case "get_repository":
+		return scoped.handleGetRepository(ctx, arguments)
+	case "list_pipelines":
+		return scoped.handleListPipelines(ctx, arguments)
+	case "get_pipeline_status":
+		return scoped.handleGetPipelineStatus(ctx, arguments)
+	case "get_pipeline_steps":
+		return scoped.handleGetPipelineSteps(ctx, arguments)
+	case "diagnose_pipeline":
+		return scoped.handleDiagnosePipeline(ctx, arguments)
+	case "wait_for_pipeline":
+		return scoped.handleWaitForPipeline(ctx, arguments)
+	case "start_pipeline":
+		return scoped.handleStartPipeline(ctx, arguments)
+	case "stop_pipeline":
+		return scoped.handleStopPipeline(ctx, arguments)
+	case "approve_pipeline":
+		return scoped.handleApprovePipeline(ctx, arguments)
+	case "trigger_pipeline":
+		return scoped.handleTriggerPipeline(ctx, arguments)
+	case "get_logs":
+		return scoped.handleGetLogs(ctx, arguments)
+	case "search_logs":
+		return scoped.handleSearchLogs(ctx, arguments)
+	case "follow_logs":
+		return scoped.handleFollowLogs(ctx, arguments)
+	case "list_secrets":
+		return scoped.handleListSecrets(ctx, arguments)
+	case "create_secret":
+		return scoped.handleCreateSecret(ctx, arguments)
+	case "update_secret":
+		return scoped.handleUpdateSecret(ctx, arguments)
+	case "delete_secret":
+		return scoped.handleDeleteSecret(ctx, arguments)
+	case "list_registries":
+		return scoped.handleListRegistries(ctx, arguments)
+	case "create_registry":
+		return scoped.handleCreateRegistry(ctx, arguments)
+	case "update_registry":
+		return scoped.handleUpdateRegistry(ctx, arguments)
+	case "delete_registry":
+		return scoped.handleDeleteRegistry(ctx, arguments)
+	case "check_registry_images":
+		return scoped.handleCheckRegistryImages(ctx, arguments)
+	case "list_crons":
+		return scoped.handleListCrons(ctx, arguments)
+	case "get_cron":
+		return scoped.handleGetCron(ctx, arguments)
+	case "create_cron":
+		return scoped.handleCreateCron(ctx, arguments)
+	case "update_cron":
+		return scoped.handleUpdateCron(ctx, arguments)
+	case "delete_cron":
+		return scoped.handleDeleteCron(ctx, arguments)
+	case "run_cron_now":
+		return scoped.handleRunCronNow(ctx, arguments)
+	case "list_agents":
+		return scoped.handleListAgents(ctx, arguments)
+	case "get_agent":
+		return scoped.handleGetAgent(ctx, arguments)
+	case "get_queue_info":
+		return scoped.handleGetQueueInfo(ctx, arguments)
+	case "explain_pending_workflow":
+		return scoped.handleExplainPendingWorkflow(ctx, arguments)
+	case "lint_config":
+		return scoped.handleLintConfig(ctx, arguments)
+	default:
+		return tm.errorResult(client.Validationf("unknown tool: %s", tool.Name)), nil
 	}
 }
 
@@ -331,10 +1271,10 @@ func (tm *ToolManager) handleListRepositories(ctx context.Context, arguments map
 		return cancelled, nil
 	}
 
-	repositories, err := tm.client.ListRepositories()
+	repositories, err := tm.client.ListRepositories(ctx)
 	if err != nil {
 		tm.logger.WithError(err).Error("Failed to list repositories")
-		return tm.errorResult(fmt.Sprintf("Failed to list repositories: %v", err)), nil
+		return tm.errorResult(err), nil
 	}
 
 	showAll := getBool(arguments, "all", false)
@@ -362,14 +1302,14 @@ func (tm *ToolManager) handleGetRepository(ctx context.Context, arguments map[st
 		return cancelled, nil
 	}
 
-	repoID, err := getRepoID(tm.client, arguments)
+	repoID, err := getRepoID(ctx, tm.client, arguments)
 	if err != nil {
-		return tm.errorResult(err.Error()), nil
+		return tm.errorResult(err), nil
 	}
 
-	repo, err := tm.client.GetRepository(repoID)
+	repo, err := tm.client.GetRepository(ctx, repoID)
 	if err != nil {
-		return tm.errorResult(fmt.Sprintf("Failed to get repository: %v", err)), nil
+		return tm.errorResult(err), nil
 	}
 
 	return tm.jsonResult(repo)
@@ -380,14 +1320,14 @@ func (tm *ToolManager) handleListPipelines(ctx context.Context, arguments map[st
 		return cancelled, nil
 	}
 
-	repoID, err := getRepoID(tm.client, arguments)
+	repoID, err := getRepoID(ctx, tm.client, arguments)
 	if err != nil {
-		return tm.errorResult(err.Error()), nil
+		return tm.errorResult(err), nil
 	}
 
-	pipelines, err := tm.client.ListPipelines(repoID)
+	opt, err := getPipelineListOptions(arguments)
 	if err != nil {
-		return tm.errorResult(fmt.Sprintf("Failed to list pipelines: %v", err)), nil
+		return tm.errorResult(err), nil
 	}
 
 	// Apply limit with default of 10 and max of 100
@@ -399,19 +1339,35 @@ func (tm *ToolManager) handleListPipelines(ctx context.Context, arguments map[st
 		limit = 10
 	}
 
-	totalCount := len(pipelines)
-	limited := totalCount > int(limit)
+	perPage := int(getNumber(arguments, "per_page", defaultPerPage))
+	if perPage > maxPerPage {
+		perPage = maxPerPage
+	}
 
-	if limited {
-		pipelines = pipelines[:int(limit)]
+	start := pageCursor{
+		Page:    int(getNumber(arguments, "page", 1)),
+		PerPage: perPage,
+		Offset:  int(getNumber(arguments, "offset", 0)),
+	}
+
+	fetch := func(ctx context.Context, page, perPage int) ([]*woodpecker.Pipeline, error) {
+		pageOpt := opt
+		pageOpt.Page = page
+		pageOpt.PerPage = perPage
+		return tm.client.ListPipelines(ctx, repoID, pageOpt)
+	}
+
+	pipelines, next, err := walkPages(ctx, fetch, start, int(limit))
+	if err != nil {
+		return tm.errorResult(err), nil
 	}
 
 	response := map[string]interface{}{
 		"repo_id":     repoID,
 		"pipelines":   pipelines,
-		"total_count": totalCount,
 		"returned":    len(pipelines),
-		"limited":     limited,
+		"has_more":    next != nil,
+		"next_cursor": next,
 	}
 
 	return tm.jsonResult(response)
@@ -422,26 +1378,26 @@ func (tm *ToolManager) handleGetPipelineStatus(ctx context.Context, arguments ma
 		return cancelled, nil
 	}
 
-	repoID, err := getRepoID(tm.client, arguments)
+	repoID, err := getRepoID(ctx, tm.client, arguments)
 	if err != nil {
-		return tm.errorResult(err.Error()), nil
+		return tm.errorResult(err), nil
 	}
 
 	var pipeline interface{}
 	latest := getBool(arguments, "latest", false)
 
 	if latest {
-		pipeline, err = tm.client.GetLastPipeline(repoID)
+		pipeline, err = tm.client.GetLastPipeline(ctx, repoID)
 	} else {
 		pipelineNum, numErr := requireNumber(arguments, "pipeline_number")
 		if numErr != nil {
-			return tm.errorResult("Either pipeline_number or latest=true must be provided"), nil
+			return tm.errorResult(client.Validationf("either pipeline_number or latest=true must be provided")), nil
 		}
-		pipeline, err = tm.client.GetPipeline(repoID, int64(pipelineNum))
+		pipeline, err = tm.client.GetPipeline(ctx, repoID, int64(pipelineNum))
 	}
 
 	if err != nil {
-		return tm.errorResult(fmt.Sprintf("Failed to get pipeline: %v", err)), nil
+		return tm.errorResult(err), nil
 	}
 
 	return tm.jsonResult(pipeline)
@@ -452,14 +1408,14 @@ func (tm *ToolManager) handleStartPipeline(ctx context.Context, arguments map[st
 		return cancelled, nil
 	}
 
-	repoID, err := getRepoID(tm.client, arguments)
+	repoID, err := getRepoID(ctx, tm.client, arguments)
 	if err != nil {
-		return tm.errorResult(err.Error()), nil
+		return tm.errorResult(err), nil
 	}
 
 	pipelineNum, err := requireNumber(arguments, "pipeline_number")
 	if err != nil {
-		return tm.errorResult(err.Error()), nil
+		return tm.errorResult(err), nil
 	}
 
 	params := make(map[string]string)
@@ -467,9 +1423,9 @@ func (tm *ToolManager) handleStartPipeline(ctx context.Context, arguments map[st
 		params["fork"] = "true"
 	}
 
-	pipeline, err := tm.client.StartPipeline(repoID, int64(pipelineNum), params)
+	pipeline, err := tm.client.StartPipeline(ctx, repoID, int64(pipelineNum), params)
 	if err != nil {
-		return tm.errorResult(fmt.Sprintf("Failed to start pipeline: %v", err)), nil
+		return tm.errorResult(err), nil
 	}
 
 	return tm.jsonResult(pipeline)
@@ -480,19 +1436,19 @@ func (tm *ToolManager) handleStopPipeline(ctx context.Context, arguments map[str
 		return cancelled, nil
 	}
 
-	repoID, err := getRepoID(tm.client, arguments)
+	repoID, err := getRepoID(ctx, tm.client, arguments)
 	if err != nil {
-		return tm.errorResult(err.Error()), nil
+		return tm.errorResult(err), nil
 	}
 
 	pipelineNum, err := requireNumber(arguments, "pipeline_number")
 	if err != nil {
-		return tm.errorResult(err.Error()), nil
+		return tm.errorResult(err), nil
 	}
 
-	err = tm.client.StopPipeline(repoID, int64(pipelineNum))
+	err = tm.client.StopPipeline(ctx, repoID, int64(pipelineNum))
 	if err != nil {
-		return tm.errorResult(fmt.Sprintf("Failed to stop pipeline: %v", err)), nil
+		return tm.errorResult(err), nil
 	}
 
 	response := map[string]interface{}{
@@ -510,19 +1466,19 @@ func (tm *ToolManager) handleApprovePipeline(ctx context.Context, arguments map[
 		return cancelled, nil
 	}
 
-	repoID, err := getRepoID(tm.client, arguments)
+	repoID, err := getRepoID(ctx, tm.client, arguments)
 	if err != nil {
-		return tm.errorResult(err.Error()), nil
+		return tm.errorResult(err), nil
 	}
 
 	pipelineNum, err := requireNumber(arguments, "pipeline_number")
 	if err != nil {
-		return tm.errorResult(err.Error()), nil
+		return tm.errorResult(err), nil
 	}
 
-	pipeline, err := tm.client.ApprovePipeline(repoID, int64(pipelineNum))
+	pipeline, err := tm.client.ApprovePipeline(ctx, repoID, int64(pipelineNum))
 	if err != nil {
-		return tm.errorResult(fmt.Sprintf("Failed to approve pipeline: %v", err)), nil
+		return tm.errorResult(err), nil
 	}
 
 	return tm.jsonResult(pipeline)
@@ -533,22 +1489,88 @@ func (tm *ToolManager) handleTriggerPipeline(ctx context.Context, arguments map[
 		return cancelled, nil
 	}
 
-	repoID, err := getRepoID(tm.client, arguments)
+	repoID, err := getRepoID(ctx, tm.client, arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	variables, err := getVariables(arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	repo, err := tm.client.GetRepository(ctx, repoID)
 	if err != nil {
-		return tm.errorResult(err.Error()), nil
+		return tm.errorResult(err), nil
+	}
+
+	branch := triggerBranch(repo, arguments)
+	if branch == "" {
+		return tm.errorResult(client.Validationf("repository %s has no default branch; pass branch explicitly", repo.FullName)), nil
+	}
+
+	// A branch list too long to check does not stop the trigger, but the result says so
+	exists, err := tm.client.HasBranch(ctx, repoID, branch)
+	branchNote, unverified := unverifiedBranchNote(err)
+	if err != nil && !unverified {
+		return tm.errorResult(err), nil
+	}
+	if !exists && !unverified {
+		return tm.errorResult(client.Validationf("branch %q does not exist in %s (default branch: %s)", branch, repo.FullName, repo.Branch)), nil
 	}
 
-	// Build pipeline options
 	options := &woodpecker.PipelineOptions{
-		Branch: getString(arguments, "branch", "main"),
+		Branch:    branch,
+		Variables: variables,
 	}
 
-	pipeline, err := tm.client.CreatePipeline(repoID, options)
+	pipeline, err := tm.client.CreatePipeline(ctx, repoID, options)
 	if err != nil {
-		return tm.errorResult(fmt.Sprintf("Failed to trigger pipeline: %v", err)), nil
+		return tm.errorResult(err), nil
 	}
 
-	return tm.jsonResult(pipeline)
+	response := map[string]interface{}{
+		"pipeline": pipeline,
+		"web_url":  tm.client.PipelineURL(repoID, pipeline.Number),
+	}
+	if unverified {
+		response["note"] = branchNote
+	}
+	return tm.jsonResult(response)
+}
+
+// triggerBranch returns the branch trigger_pipeline will run on
+func triggerBranch(repo *woodpecker.Repo, arguments map[string]interface{}) string {
+	return getString(arguments, "branch", repo.Branch)
+}
+
+// getVariables reads the variables object; scalar values are converted to strings
+func getVariables(arguments map[string]interface{}) (map[string]string, error) {
+	raw, ok := arguments["variables"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	object, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, client.Validationf("variables must be an object of string values")
+	}
+
+	variables := make(map[string]string, len(object))
+	for key, value := range object {
+		if key == "" {
+			return nil, client.Validationf("variable names must not be empty")
+		}
+		switch v := value.(type) {
+		case string:
+			variables[key] = v
+		case float64, bool:
+			variables[key] = fmt.Sprint(v)
+		default:
+			return nil, client.Validationf("variable %s must be a string", key)
+		}
+	}
+	return variables, nil
 }
 
 func (tm *ToolManager) handleGetLogs(ctx context.Context, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
@@ -556,30 +1578,37 @@ func (tm *ToolManager) handleGetLogs(ctx context.Context, arguments map[string]i
 		return cancelled, nil
 	}
 
-	repoID, err := getRepoID(tm.client, arguments)
+	repoID, err := getRepoID(ctx, tm.client, arguments)
 	if err != nil {
-		return tm.errorResult(err.Error()), nil
+		return tm.errorResult(err), nil
 	}
 
 	pipelineNum, err := requireNumber(arguments, "pipeline_number")
 	if err != nil {
-		return tm.errorResult(err.Error()), nil
+		return tm.errorResult(err), nil
 	}
 
-	stepID, err := requireNumber(arguments, "step_id")
+	stepID, err := tm.getStepIDArg(ctx, repoID, int64(pipelineNum), arguments)
 	if err != nil {
-		return tm.errorResult(err.Error()), nil
+		return tm.errorResult(err), nil
 	}
 
 	format := getString(arguments, "format", "json")
 	lines := getNumber(arguments, "lines", 0) // 0 means all lines
 	useTail := getBool(arguments, "tail", false)
+	timestamps := getBool(arguments, "timestamps", false)
+	includeTypes := getBool(arguments, "include_types", false)
 
-	logs, err := tm.client.GetStepLogs(repoID, int64(pipelineNum), int64(stepID))
+	rawLogs, err := tm.client.GetStepLogs(ctx, repoID, int64(pipelineNum), stepID)
 	if err != nil {
-		return tm.errorResult(fmt.Sprintf("Failed to get logs: %v", err)), nil
+		return tm.errorResult(err), nil
 	}
 
+	logs := normalizeLogEntries(rawLogs, logOptions{
+		StripANSI:        getBool(arguments, "strip_ansi", true),
+		CollapseProgress: getBool(arguments, "collapse_progress", true),
+	})
+
 	totalCount := len(logs)
 	limited := false
 
@@ -598,10 +1627,22 @@ func (tm *ToolManager) handleGetLogs(ctx context.Context, arguments map[string]i
 	if format == "text" {
 		var logLines []string
 		for _, logEntry := range logs {
-			if len(logEntry.Data) > 0 {
-				// Data is already decoded as []byte from the API's JSON array of integers
-				logLines = append(logLines, string(logEntry.Data))
+			text := logEntry.Data
+			switch logEntry.Type {
+			case "exit_code", "metadata":
+				if !includeTypes {
+					continue
+				}
+				text = fmt.Sprintf("[%s] %s", logEntry.Type, text)
+			default:
+				if text == "" {
+					continue
+				}
 			}
+			if timestamps {
+				text = fmt.Sprintf("[%4ds] %s", logEntry.Time, text)
+			}
+			logLines = append(logLines, text)
 		}
 
 		var plainText string
@@ -611,36 +1652,20 @@ func (tm *ToolManager) handleGetLogs(ctx context.Context, arguments map[string]i
 				direction = "last"
 			}
 			plainText = fmt.Sprintf("Logs for repo %d, pipeline %d, step %d (%s %d of %d lines):\n%s",
-				repoID, int64(pipelineNum), int64(stepID), direction, len(logs), totalCount, strings.Join(logLines, "\n"))
+				repoID, int64(pipelineNum), stepID, direction, len(logs), totalCount, strings.Join(logLines, "\n"))
 		} else {
 			plainText = fmt.Sprintf("Logs for repo %d, pipeline %d, step %d:\n%s",
-				repoID, int64(pipelineNum), int64(stepID), strings.Join(logLines, "\n"))
+				repoID, int64(pipelineNum), stepID, strings.Join(logLines, "\n"))
 		}
 
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.TextContent{
-					Type: "text",
-					Text: plainText,
-				},
-			},
-		}, nil
-	}
-
-	// Prepare logs for JSON response (data is already decoded as []byte from the API)
-	var decodedLogs []map[string]interface{}
-	for _, logEntry := range logs {
-		decodedLogs = append(decodedLogs, map[string]interface{}{
-			"line": int(logEntry.Line),
-			"data": string(logEntry.Data),
-		})
+		return tm.textResult(plainText), nil
 	}
 
 	response := map[string]interface{}{
 		"repo_id":         repoID,
 		"pipeline_number": int64(pipelineNum),
-		"step_id":         int64(stepID),
-		"logs":            decodedLogs,
+		"step_id":         stepID,
+		"logs":            logs,
 		"total_count":     totalCount,
 		"returned":        len(logs),
 		"limited":         limited,
@@ -657,9 +1682,19 @@ func (tm *ToolManager) handleGetLogs(ctx context.Context, arguments map[string]i
 }
 
 func (tm *ToolManager) jsonResult(data interface{}) (*mcp.CallToolResult, error) {
-	jsonData, err := json.MarshalIndent(data, "", "  ")
+	data = shapeValue(data, tm.shape)
+
+	if tm.redactor != nil {
+		redacted, err := tm.redactor.redactJSON(data)
+		if err != nil {
+			return tm.errorResult(fmt.Errorf("failed to format response: %w", err)), nil
+		}
+		data = redacted
+	}
+
+	jsonData, err := fitToBudget(data, tm.shape.MaxBytes)
 	if err != nil {
-		return tm.errorResult(fmt.Sprintf("Failed to format response: %v", err)), nil
+		return tm.errorResult(fmt.Errorf("failed to format response: %w", err)), nil
 	}
 
 	return &mcp.CallToolResult{
@@ -672,18 +1707,37 @@ func (tm *ToolManager) jsonResult(data interface{}) (*mcp.CallToolResult, error)
 	}, nil
 }
 
-func (tm *ToolManager) errorResult(message string) *mcp.CallToolResult {
+// textResult returns plain text, masking secrets and noting how many were masked
+func (tm *ToolManager) textResult(text string) *mcp.CallToolResult {
+	if tm.redactor != nil {
+		var count int
+		text, count = tm.redactor.RedactString(text)
+		if count > 0 {
+			text += fmt.Sprintf("\n[%d secrets redacted]", count)
+		}
+	}
+
 	return &mcp.CallToolResult{
 		Content: []mcp.Content{
 			mcp.TextContent{
 				Type: "text",
-				Text: message,
+				Text: string(truncateText([]byte(text), tm.shape.MaxBytes)),
 			},
 		},
-		IsError: true,
 	}
 }
 
+// errorResult reports a failed tool call as a structured error body, see newErrorResult.
+// Error messages can quote upstream responses and arguments, so they are masked too.
+func (tm *ToolManager) errorResult(err error) *mcp.CallToolResult {
+	te := newToolError(err)
+	if tm.redactor != nil {
+		te.Message, _ = tm.redactor.RedactString(te.Message)
+		te.Hint, _ = tm.redactor.RedactString(te.Hint)
+	}
+	return renderToolError(te)
+}
+
 func (tm *ToolManager) handleLintConfig(ctx context.Context, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
 	if cancelled := checkContextCancelled(ctx); cancelled != nil {
 		return cancelled, nil
@@ -691,18 +1745,18 @@ func (tm *ToolManager) handleLintConfig(ctx context.Context, arguments map[strin
 
 	filePath := getString(arguments, "path", "")
 	if filePath == "" {
-		return tm.errorResult("path is required"), nil
+		return tm.errorResult(client.Validationf("path is required")), nil
 	}
 
 	// Validate file extension
 	if !strings.HasSuffix(filePath, ".yaml") && !strings.HasSuffix(filePath, ".yml") {
-		return tm.errorResult("path must be a .yaml or .yml file"), nil
+		return tm.errorResult(client.Validationf("path must be a .yaml or .yml file")), nil
 	}
 
 	// Read the file
 	buf, err := os.ReadFile(filePath)
 	if err != nil {
-		return tm.errorResult(fmt.Sprintf("Failed to read file: %v", err)), nil
+		return tm.errorResult(client.Validationf("failed to read file: %v", err)), nil
 	}
 
 	rawConfig := string(buf)
@@ -710,7 +1764,7 @@ func (tm *ToolManager) handleLintConfig(ctx context.Context, arguments map[strin
 	// Parse YAML
 	parsedConfig, err := yaml.ParseString(rawConfig)
 	if err != nil {
-		return tm.errorResult(fmt.Sprintf("Failed to parse YAML: %v", err)), nil
+		return tm.errorResult(client.Validationf("failed to parse YAML: %v", err)), nil
 	}
 
 	// Create WorkflowConfig
@@ -0,0 +1,67 @@
+package tools
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sirupsen/logrus"
+)
+
+type progressTokenKey struct{}
+
+// withProgressToken keeps the caller's progress token, if it sent one, for handlers that report progress
+func withProgressToken(ctx context.Context, meta *mcp.Meta) context.Context {
+	if meta == nil || meta.ProgressToken == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, progressTokenKey{}, meta.ProgressToken)
+}
+
+// notifier forwards interim output of a long-running tool call to the calling client:
+// as progress notifications when the caller sent a progress token, otherwise as log
+// messages, which the client filters by the log level it has set
+type notifier struct {
+	server   *server.MCPServer
+	token    mcp.ProgressToken
+	name     string
+	logger   *logrus.Logger
+	progress float64
+	failed   bool
+}
+
+func (tm *ToolManager) newNotifier(ctx context.Context, name string) *notifier {
+	token, _ := ctx.Value(progressTokenKey{}).(mcp.ProgressToken)
+	return &notifier{
+		server: server.ServerFromContext(ctx),
+		token:  token,
+		name:   name,
+		logger: tm.logger,
+	}
+}
+
+// notify sends message, with data as the payload of log messages. Delivery is best
+// effort; once the session turns out not to accept notifications the rest are dropped.
+func (n *notifier) notify(ctx context.Context, message string, data map[string]interface{}) {
+	if n.server == nil || n.failed {
+		return
+	}
+
+	n.progress++
+	var err error
+	if n.token != nil {
+		err = n.server.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+			"progressToken": n.token,
+			"progress":      n.progress,
+			"message":       message,
+		})
+	} else {
+		err = n.server.SendLogMessageToClient(ctx, mcp.NewLoggingMessageNotification(mcp.LoggingLevelInfo, n.name, data))
+	}
+
+	if err != nil {
+		n.failed = errors.Is(err, server.ErrNotificationNotInitialized) || errors.Is(err, server.ErrSessionDoesNotSupportLogging)
+		n.logger.WithError(err).WithField("tool", n.name).Debug("Failed to notify client")
+	}
+}
@@ -0,0 +1,162 @@
+package tools
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+)
+
+const (
+	// defaultPerPage is the page size requested from Woodpecker when the caller does not set per_page
+	defaultPerPage = 25
+	// maxPerPage mirrors the largest page size the Woodpecker API accepts
+	maxPerPage = 50
+	// maxPageFetches bounds how many upstream pages a single tool call may walk
+	maxPageFetches = 20
+)
+
+// pageCursor identifies where a paginated listing should resume.
+// Offset is the number of items already consumed from Page.
+type pageCursor struct {
+	Page    int `json:"page"`
+	PerPage int `json:"per_page"`
+	Offset  int `json:"offset,omitempty"`
+}
+
+// pageFetcher returns a single page (1-based) of results
+type pageFetcher[T any] func(ctx context.Context, page, perPage int) ([]T, error)
+
+// walkPages fetches consecutive pages starting at start until limit items have been
+// collected or the upstream runs out. It returns the collected items and the cursor
+// for the next unread item, or nil when there are no more results.
+func walkPages[T any](ctx context.Context, fetch pageFetcher[T], start pageCursor, limit int) ([]T, *pageCursor, error) {
+	if start.Page < 1 {
+		start.Page = 1
+	}
+	if start.PerPage < 1 {
+		start.PerPage = defaultPerPage
+	}
+	if start.Offset < 0 {
+		start.Offset = 0
+	}
+
+	var items []T
+	page := start.Page
+	offset := start.Offset
+
+	for fetches := 0; fetches < maxPageFetches; fetches++ {
+		batch, err := fetch(ctx, page, start.PerPage)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		lastPage := len(batch) < start.PerPage
+		if offset > len(batch) {
+			offset = len(batch)
+		}
+		batch = batch[offset:]
+
+		remaining := limit - len(items)
+		if len(batch) > remaining {
+			items = append(items, batch[:remaining]...)
+			return items, &pageCursor{Page: page, PerPage: start.PerPage, Offset: offset + remaining}, nil
+		}
+		items = append(items, batch...)
+
+		if lastPage {
+			return items, nil, nil
+		}
+
+		page++
+		offset = 0
+
+		if len(items) == limit {
+			return items, &pageCursor{Page: page, PerPage: start.PerPage}, nil
+		}
+	}
+
+	return items, &pageCursor{Page: page, PerPage: start.PerPage}, nil
+}
+
+// getPipelineListOptions builds server-side pipeline filters from tool arguments
+func getPipelineListOptions(arguments map[string]interface{}) (woodpecker.PipelineListOptions, error) {
+	var opt woodpecker.PipelineListOptions
+
+	opt.Branch = getString(arguments, "branch", "")
+	opt.Status = getString(arguments, "status", "")
+
+	events, err := getStringList(arguments, "event")
+	if err != nil {
+		return opt, err
+	}
+	opt.Events = events
+
+	if opt.Before, err = getTime(arguments, "before"); err != nil {
+		return opt, err
+	}
+	if opt.After, err = getTime(arguments, "after"); err != nil {
+		return opt, err
+	}
+
+	return opt, nil
+}
+
+// getStringList accepts either a comma-separated string or an array of strings
+func getStringList(arguments map[string]interface{}, key string) ([]string, error) {
+	val, ok := arguments[key]
+	if !ok {
+		return nil, nil
+	}
+
+	var values []string
+	switch v := val.(type) {
+	case string:
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				return nil, client.Validationf("%s must contain only strings", key)
+			}
+			values = append(values, str)
+		}
+	default:
+		return nil, client.Validationf("%s must be a string or an array of strings", key)
+	}
+
+	return values, nil
+}
+
+// getTime parses an RFC 3339 timestamp or unix seconds, returning the zero time if absent
+func getTime(arguments map[string]interface{}, key string) (time.Time, error) {
+	val, ok := arguments[key]
+	if !ok {
+		return time.Time{}, nil
+	}
+
+	switch v := val.(type) {
+	case float64:
+		return time.Unix(int64(v), 0), nil
+	case string:
+		// The schema declares a string, so unix seconds may arrive quoted
+		if secs, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+			return time.Unix(secs, 0), nil
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, client.Validationf("%s must be an RFC 3339 timestamp or unix seconds: %v", key, err)
+		}
+		return t, nil
+	default:
+		return time.Time{}, client.Validationf("%s must be an RFC 3339 timestamp or unix seconds", key)
+	}
+}
@@ -0,0 +1,105 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakePages serves total sequential integers in pages and records which pages were requested
+func fakePages(total int, requested *[]int) pageFetcher[int] {
+	return func(ctx context.Context, page, perPage int) ([]int, error) {
+		*requested = append(*requested, page)
+		var out []int
+		for i := (page - 1) * perPage; i < page*perPage && i < total; i++ {
+			out = append(out, i)
+		}
+		return out, nil
+	}
+}
+
+func TestWalkPages_WalksUntilLimit(t *testing.T) {
+	var requested []int
+	items, next, err := walkPages(context.Background(), fakePages(100, &requested), pageCursor{PerPage: 10}, 25)
+
+	require.NoError(t, err)
+	require.Len(t, items, 25)
+	require.Equal(t, 0, items[0])
+	require.Equal(t, 24, items[24])
+	require.Equal(t, []int{1, 2, 3}, requested)
+	require.Equal(t, &pageCursor{Page: 3, PerPage: 10, Offset: 5}, next)
+}
+
+func TestWalkPages_ResumesFromCursor(t *testing.T) {
+	var requested []int
+	items, next, err := walkPages(context.Background(), fakePages(100, &requested), pageCursor{Page: 3, PerPage: 10, Offset: 5}, 10)
+
+	require.NoError(t, err)
+	require.Equal(t, []int{25, 26, 27, 28, 29, 30, 31, 32, 33, 34}, items)
+	require.Equal(t, &pageCursor{Page: 4, PerPage: 10, Offset: 5}, next)
+}
+
+func TestWalkPages_PageAlignedLimit(t *testing.T) {
+	var requested []int
+	items, next, err := walkPages(context.Background(), fakePages(100, &requested), pageCursor{PerPage: 10}, 20)
+
+	require.NoError(t, err)
+	require.Len(t, items, 20)
+	require.Equal(t, []int{1, 2}, requested)
+	require.Equal(t, &pageCursor{Page: 3, PerPage: 10}, next)
+}
+
+func TestWalkPages_StopsAtLastPage(t *testing.T) {
+	var requested []int
+	items, next, err := walkPages(context.Background(), fakePages(15, &requested), pageCursor{PerPage: 10}, 50)
+
+	require.NoError(t, err)
+	require.Len(t, items, 15)
+	require.Equal(t, []int{1, 2}, requested)
+	require.Nil(t, next)
+}
+
+func TestWalkPages_PropagatesError(t *testing.T) {
+	fetch := func(ctx context.Context, page, perPage int) ([]int, error) {
+		return nil, errors.New("boom")
+	}
+
+	items, next, err := walkPages[int](context.Background(), fetch, pageCursor{}, 10)
+
+	require.Error(t, err)
+	require.Nil(t, items)
+	require.Nil(t, next)
+}
+
+func TestGetPipelineListOptions(t *testing.T) {
+	arguments := map[string]interface{}{
+		"branch": "main",
+		"status": "failure",
+		"event":  "push, pull_request",
+		"before": "2024-05-01T00:00:00Z",
+		"after":  float64(1700000000),
+	}
+
+	opt, err := getPipelineListOptions(arguments)
+
+	require.NoError(t, err)
+	require.Equal(t, "main", opt.Branch)
+	require.Equal(t, "failure", opt.Status)
+	require.Equal(t, []string{"push", "pull_request"}, opt.Events)
+	require.True(t, opt.Before.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)))
+	require.Equal(t, int64(1700000000), opt.After.Unix())
+
+	opt, err = getPipelineListOptions(map[string]interface{}{"before": "1700000000"})
+	require.NoError(t, err)
+	require.Equal(t, int64(1700000000), opt.Before.Unix())
+}
+
+func TestGetPipelineListOptions_InvalidTime(t *testing.T) {
+	_, err := getPipelineListOptions(map[string]interface{}{"before": "yesterday"})
+
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "before must be an RFC 3339 timestamp")
+}
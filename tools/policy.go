@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/policy"
+)
+
+// SetPolicy makes every mutating tool call pass p before it reaches Woodpecker
+func (tm *ToolManager) SetPolicy(p *policy.Policy) {
+	tm.policy = p
+}
+
+// enforcePolicy resolves the repository and, when a rule needs it, the branch a
+// mutating call targets and rejects the call if the policy denies it.
+// getRepoID and getOrgArg pin the resolved repo_id or org into arguments, so the
+// handler acts on exactly what was checked.
+func (tm *ToolManager) enforcePolicy(ctx context.Context, tool mcp.Tool, arguments map[string]interface{}) error {
+	if tm.policy == nil || isReadOnly(tool) || !tm.policy.Applies(tool.Name) {
+		return nil
+	}
+
+	targets, err := tm.policyTargets(ctx, tool.Name, arguments)
+	if err != nil {
+		return err
+	}
+
+	// A call acting on several targets is denied if any of them is
+	for _, target := range targets {
+		decision := tm.policy.Evaluate(target)
+		if decision.Allowed {
+			continue
+		}
+
+		tm.logger.WithFields(logrus.Fields{
+			"tool":    tool.Name,
+			"repo":    target.RepoName,
+			"repo_id": target.RepoID,
+			"branch":  target.Branch,
+			"scope":   target.Scope,
+			"rule":    decision.Rule,
+		}).Warn("Tool call denied by policy")
+
+		return &client.Error{Code: client.CodePolicyDenied, Message: decision.Reason}
+	}
+	return nil
+}
+
+// policyTargets resolves what a call acts on: an organization or the whole server
+// for calls scoped that way, otherwise a repository and, where known, a branch.
+// Moving a cron job to another branch acts on both its current and its new branch.
+func (tm *ToolManager) policyTargets(ctx context.Context, tool string, arguments map[string]interface{}) ([]policy.Target, error) {
+	switch getToolScope(tool, arguments) {
+	case client.SecretLevelGlobal:
+		return []policy.Target{{Tool: tool, Scope: "global"}}, nil
+	case client.SecretLevelOrg:
+		org, err := tm.getOrgArg(ctx, arguments)
+		if err != nil {
+			return nil, err
+		}
+		return []policy.Target{{Tool: tool, Scope: "org " + org}}, nil
+	}
+
+	repoID, err := getRepoID(ctx, tm.client, arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := tm.client.GetRepository(ctx, repoID)
+	if err != nil {
+		return nil, err
+	}
+
+	target := policy.Target{
+		Tool:     tool,
+		RepoID:   repoID,
+		RepoName: repo.FullName,
+		Branch:   getString(arguments, "branch", ""),
+	}
+
+	switch tool {
+	case "trigger_pipeline":
+		target.Branch = triggerBranch(repo, arguments)
+	case "create_cron":
+		target.Branch = getString(arguments, "branch", repo.Branch)
+	}
+
+	if !tm.policy.NeedsBranch(tool) {
+		return []policy.Target{target}, nil
+	}
+
+	if pipelineNum, ok := arguments["pipeline_number"].(float64); ok && target.Branch == "" {
+		pipeline, err := tm.client.GetPipeline(ctx, repoID, int64(pipelineNum))
+		if err != nil {
+			return nil, err
+		}
+		target.Branch = pipeline.Branch
+	}
+
+	if cronID, ok := arguments["cron_id"].(float64); ok {
+		cron, err := tm.client.GetCron(ctx, repoID, int64(cronID))
+		if err != nil {
+			return nil, err
+		}
+		current := target
+		// A cron without a branch runs on the default branch
+		current.Branch = cron.Branch
+		if current.Branch == "" {
+			current.Branch = repo.Branch
+		}
+		if target.Branch != "" && target.Branch != current.Branch {
+			return []policy.Target{current, target}, nil
+		}
+		return []policy.Target{current}, nil
+	}
+
+	return []policy.Target{target}, nil
+}
@@ -0,0 +1,143 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/config"
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/policy"
+)
+
+// newTestToolManager returns a ToolManager backed by a fake Woodpecker server that
+// knows repo 7 (org/prod-api), its pipeline 3 on main and its cron 5 on main
+func newTestToolManager(t *testing.T) *ToolManager {
+	t.Helper()
+
+	return newFakeWoodpecker(t, map[string]http.HandlerFunc{
+		"/api/repos/7":             jsonRoute(`{"id": 7, "full_name": "org/prod-api", "default_branch": "main"}`),
+		"/api/repos/7/pipelines/3": jsonRoute(`{"id": 30, "number": 3, "branch": "main", "commit": "abc123", "status": "running"}`),
+		"/api/repos/7/cron/5":      jsonRoute(`{"id": 5, "name": "nightly", "schedule": "@daily", "branch": "main"}`),
+	})
+}
+
+func newPolicyTestManager(t *testing.T, rules ...config.PolicyRule) *ToolManager {
+	t.Helper()
+
+	p, err := policy.New(config.PolicyConfig{Rules: rules})
+	require.NoError(t, err)
+
+	tm := newTestToolManager(t)
+	tm.SetPolicy(p)
+	return tm
+}
+
+func TestEnforcePolicy_DeniesByPipelineBranch(t *testing.T) {
+	tm := newPolicyTestManager(t, config.PolicyRule{
+		Effect:   policy.EffectDeny,
+		Tools:    []string{"stop_pipeline"},
+		Repos:    []string{"org/prod-*"},
+		Branches: []string{"main"},
+	})
+
+	tool := mcp.Tool{Name: "stop_pipeline", Annotations: mutatingTool(true)}
+	err := tm.enforcePolicy(context.Background(), tool, map[string]interface{}{
+		"repo_id":         float64(7),
+		"pipeline_number": float64(3),
+	})
+	require.ErrorIs(t, err, client.ErrPolicyDenied)
+
+	result := newErrorResult(err)
+	require.Equal(t, client.CodePolicyDenied, decodeToolError(t, result).Code)
+}
+
+func TestEnforcePolicy_AllowsOtherBranch(t *testing.T) {
+	tm := newPolicyTestManager(t, config.PolicyRule{
+		Effect:   policy.EffectDeny,
+		Tools:    []string{"trigger_pipeline"},
+		Branches: []string{"main"},
+	})
+
+	tool := mcp.Tool{Name: "trigger_pipeline", Annotations: mutatingTool(false)}
+	arguments := map[string]interface{}{
+		"repo_id": float64(7),
+		"branch":  "feature/x",
+	}
+	require.NoError(t, tm.enforcePolicy(context.Background(), tool, arguments))
+	require.Equal(t, float64(7), arguments["repo_id"])
+}
+
+func TestEnforcePolicy_TriggerUsesDefaultBranch(t *testing.T) {
+	tm := newPolicyTestManager(t, config.PolicyRule{
+		Effect:   policy.EffectDeny,
+		Tools:    []string{"trigger_pipeline"},
+		Branches: []string{"main"},
+	})
+
+	tool := mcp.Tool{Name: "trigger_pipeline", Annotations: mutatingTool(false)}
+	err := tm.enforcePolicy(context.Background(), tool, map[string]interface{}{"repo_id": float64(7)})
+	require.ErrorIs(t, err, client.ErrPolicyDenied)
+}
+
+func TestEnforcePolicy_UpdateCronChecksCurrentBranch(t *testing.T) {
+	tm := newPolicyTestManager(t, config.PolicyRule{
+		Effect:   policy.EffectDeny,
+		Tools:    []string{"update_cron"},
+		Branches: []string{"main"},
+	})
+
+	// Moving a cron off a protected branch is a change to that branch
+	tool := mcp.Tool{Name: "update_cron", Annotations: mutatingTool(true)}
+	err := tm.enforcePolicy(context.Background(), tool, map[string]interface{}{
+		"repo_id": float64(7),
+		"cron_id": float64(5),
+		"branch":  "feature",
+	})
+	require.ErrorIs(t, err, client.ErrPolicyDenied)
+
+	// Moving one onto it is denied too
+	tm = newPolicyTestManager(t, config.PolicyRule{
+		Effect:   policy.EffectDeny,
+		Tools:    []string{"update_cron"},
+		Branches: []string{"release"},
+	})
+	err = tm.enforcePolicy(context.Background(), tool, map[string]interface{}{
+		"repo_id": float64(7),
+		"cron_id": float64(5),
+		"branch":  "release",
+	})
+	require.ErrorIs(t, err, client.ErrPolicyDenied)
+
+	require.NoError(t, tm.enforcePolicy(context.Background(), tool, map[string]interface{}{
+		"repo_id": float64(7),
+		"cron_id": float64(5),
+		"branch":  "feature",
+	}))
+}
+
+func TestEnforcePolicy_IgnoresScopeOfUnscopedTools(t *testing.T) {
+	tm := newPolicyTestManager(t, config.PolicyRule{
+		Effect:   policy.EffectAllow,
+		Tools:    []string{"trigger_pipeline"},
+		Branches: []string{"main"},
+	}, config.PolicyRule{Effect: policy.EffectDeny})
+
+	// scope means nothing to trigger_pipeline, so it is checked by repository and branch
+	tool := mcp.Tool{Name: "trigger_pipeline", Annotations: mutatingTool(false)}
+	require.NoError(t, tm.enforcePolicy(context.Background(), tool, map[string]interface{}{
+		"repo_id": float64(7),
+		"scope":   "global",
+	}))
+}
+
+func TestEnforcePolicy_SkipsReadOnlyTools(t *testing.T) {
+	tm := newPolicyTestManager(t, config.PolicyRule{Effect: policy.EffectDeny})
+
+	// No repository is given, so resolving the target would fail if it were attempted
+	tool := mcp.Tool{Name: "list_pipelines", Annotations: readOnlyTool()}
+	require.NoError(t, tm.enforcePolicy(context.Background(), tool, map[string]interface{}{}))
+}
@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+)
+
+const (
+	// minSecretLength and minSecretEntropy tune the generic token detector. Hex
+	// strings such as commit SHAs stay below the entropy threshold.
+	minSecretLength  = 32
+	minSecretEntropy = 4.2
+)
+
+type redactionRule struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// builtinRedactionRules are applied before user patterns; private keys go first so
+// their base64 body is replaced as one block
+var builtinRedactionRules = []redactionRule{
+	{"private_key", regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{10,}`)},
+	{"aws_access_key", regexp.MustCompile(`\b(AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA|AIPA)[0-9A-Z]{16}\b`)},
+	{"aws_secret_key", regexp.MustCompile(`(?i)(aws_?secret_?access_?key|aws_?secret)(["'\s:=]+)[A-Za-z0-9/+=]{40}`)},
+}
+
+// secretCandidate matches strings that may be opaque tokens; they are only
+// redacted when they are also high-entropy and mix character classes
+var secretCandidate = regexp.MustCompile(`[A-Za-z0-9_+=-]{32,}`)
+
+// Redactor masks credentials in tool results before they reach the agent
+type Redactor struct {
+	rules []redactionRule
+}
+
+// NewRedactor returns a Redactor with the built-in rules plus patterns
+func NewRedactor(patterns []string) (*Redactor, error) {
+	r := &Redactor{rules: append([]redactionRule{}, builtinRedactionRules...)}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		r.rules = append(r.rules, redactionRule{name: "custom", re: re})
+	}
+	return r, nil
+}
+
+// SetRedactor masks secrets in every tool result with r
+func (tm *ToolManager) SetRedactor(r *Redactor) {
+	tm.redactor = r
+}
+
+// RedactString returns s with secrets masked and the number of masked values
+func (r *Redactor) RedactString(s string) (string, int) {
+	count := 0
+	for _, rule := range r.rules {
+		s = rule.re.ReplaceAllStringFunc(s, func(match string) string {
+			count++
+			if rule.name == "aws_secret_key" {
+				// Keep the variable name so the output stays readable
+				sub := rule.re.FindStringSubmatch(match)
+				return sub[1] + sub[2] + "[REDACTED:aws_secret_key]"
+			}
+			return "[REDACTED:" + rule.name + "]"
+		})
+	}
+
+	s = secretCandidate.ReplaceAllStringFunc(s, func(match string) string {
+		if !looksLikeSecret(match) {
+			return match
+		}
+		count++
+		return "[REDACTED:token]"
+	})
+
+	return s, count
+}
+
+// redactValue masks secrets in every string of a decoded JSON value
+func (r *Redactor) redactValue(v interface{}) (interface{}, int) {
+	switch v := v.(type) {
+	case string:
+		return r.RedactString(v)
+	case map[string]interface{}:
+		total := 0
+		for k, item := range v {
+			redacted, n := r.redactValue(item)
+			v[k] = redacted
+			total += n
+		}
+		return v, total
+	case []interface{}:
+		total := 0
+		for i, item := range v {
+			redacted, n := r.redactValue(item)
+			v[i] = redacted
+			total += n
+		}
+		return v, total
+	}
+	return v, 0
+}
+
+// redactJSON masks secrets in data after a JSON round trip, so structs are covered
+// without knowing their fields. A non-zero count is reported as "redactions".
+func (r *Redactor) redactJSON(data interface{}) (interface{}, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+
+	redacted, count := r.redactValue(decoded)
+	if count == 0 {
+		return redacted, nil
+	}
+
+	if object, ok := redacted.(map[string]interface{}); ok {
+		object["redactions"] = count
+		return object, nil
+	}
+	return map[string]interface{}{"result": redacted, "redactions": count}, nil
+}
+
+// looksLikeSecret requires high per-character entropy and a mix of upper case,
+// lower case and digits, which rules out hex digests, UUIDs and identifiers
+func looksLikeSecret(s string) bool {
+	if len(s) < minSecretLength {
+		return false
+	}
+
+	var upper, lower, digit bool
+	for _, c := range s {
+		switch {
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		case c >= 'a' && c <= 'z':
+			lower = true
+		case c >= '0' && c <= '9':
+			digit = true
+		}
+	}
+	if !upper || !lower || !digit {
+		return false
+	}
+
+	return shannonEntropy(s) >= minSecretEntropy
+}
+
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, c := range s {
+		counts[c]++
+	}
+
+	entropy := 0.0
+	n := float64(len(s))
+	for _, c := range counts {
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
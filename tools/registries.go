@@ -0,0 +1,342 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+)
+
+// Image statuses reported by check_registry_images
+const (
+	imageHasCredentials = "credentials"
+	imageAnonymous      = "anonymous"
+	imageUnresolved     = "unresolved"
+)
+
+// registryView is a set of registry credentials as returned by the registry tools.
+// It has no password field: passwords are write-only.
+type registryView struct {
+	ID       int64  `json:"id"`
+	Address  string `json:"address"`
+	Username string `json:"username"`
+	Scope    string `json:"scope"`
+	// Active and OverriddenBy are only set when several levels are listed together
+	Active       *bool  `json:"active,omitempty"`
+	OverriddenBy string `json:"overridden_by,omitempty"`
+}
+
+func newRegistryView(r *woodpecker.Registry, level string) registryView {
+	return registryView{ID: r.ID, Address: r.Address, Username: r.Username, Scope: level}
+}
+
+// resolveRegistries marks which credentials are used for each registry host.
+// views must be ordered from the most specific level: Woodpecker prefers
+// repository credentials over organization credentials for the same registry.
+func resolveRegistries(views []registryView) {
+	winners := make(map[string]string, len(views))
+	for i := range views {
+		host := normalizeRegistryAddress(views[i].Address)
+		active := true
+		if level, ok := winners[host]; ok {
+			active = false
+			views[i].OverriddenBy = level
+		} else {
+			winners[host] = views[i].Scope
+		}
+		views[i].Active = &active
+	}
+}
+
+// getRegistryScope resolves the scope argument like getSecretScope; registry
+// credentials have no global level
+func (tm *ToolManager) getRegistryScope(ctx context.Context, arguments map[string]interface{}, level string) (client.SecretScope, map[string]interface{}, error) {
+	if level != client.SecretLevelRepo && level != client.SecretLevelOrg {
+		return client.SecretScope{}, nil, client.Validationf("scope must be repo or org, got %q", level)
+	}
+	return tm.getSecretScope(ctx, arguments, level)
+}
+
+// collectRegistries lists the repository's and its organization's credentials,
+// resolved as a pipeline of the repository sees them. Organization credentials
+// the token cannot read are reported in unavailable rather than failing the call.
+func (tm *ToolManager) collectRegistries(ctx context.Context, arguments map[string]interface{}) ([]registryView, map[string]interface{}, map[string]string, error) {
+	fields := map[string]interface{}{}
+	views := []registryView{}
+	unavailable := map[string]string{}
+
+	for _, level := range []string{client.SecretLevelRepo, client.SecretLevelOrg} {
+		scope, scopeFields, err := tm.getRegistryScope(ctx, arguments, level)
+		var registries []*woodpecker.Registry
+		if err == nil {
+			registries, err = tm.client.ListRegistries(ctx, scope)
+		}
+		if err != nil {
+			if level == client.SecretLevelRepo || !(errors.Is(err, client.ErrForbidden) || errors.Is(err, client.ErrNotFound) || errors.Is(err, client.ErrUnauthorized)) {
+				return nil, nil, nil, err
+			}
+			unavailable[level] = err.Error()
+			continue
+		}
+
+		for k, v := range scopeFields {
+			if k != "scope" {
+				fields[k] = v
+			}
+		}
+		for _, r := range registries {
+			views = append(views, newRegistryView(r, level))
+		}
+	}
+
+	resolveRegistries(views)
+	return views, fields, unavailable, nil
+}
+
+func (tm *ToolManager) handleListRegistries(ctx context.Context, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if cancelled := checkContextCancelled(ctx); cancelled != nil {
+		return cancelled, nil
+	}
+
+	level := getString(arguments, "scope", secretScopeAll)
+	if level != secretScopeAll {
+		scope, response, err := tm.getRegistryScope(ctx, arguments, level)
+		if err != nil {
+			return tm.errorResult(err), nil
+		}
+
+		registries, err := tm.client.ListRegistries(ctx, scope)
+		if err != nil {
+			return tm.errorResult(err), nil
+		}
+
+		views := make([]registryView, 0, len(registries))
+		for _, r := range registries {
+			views = append(views, newRegistryView(r, level))
+		}
+		response["registries"] = views
+		response["count"] = len(views)
+		return tm.jsonResult(response)
+	}
+
+	views, response, unavailable, err := tm.collectRegistries(ctx, arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	response["scope"] = secretScopeAll
+	response["registries"] = views
+	response["count"] = len(views)
+	if len(unavailable) > 0 {
+		response["unavailable"] = unavailable
+	}
+	return tm.jsonResult(response)
+}
+
+func (tm *ToolManager) handleCreateRegistry(ctx context.Context, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if cancelled := checkContextCancelled(ctx); cancelled != nil {
+		return cancelled, nil
+	}
+
+	registry, err := getRegistryArg(arguments, true)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	level := getString(arguments, "scope", client.SecretLevelRepo)
+	scope, response, err := tm.getRegistryScope(ctx, arguments, level)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	created, err := tm.client.CreateRegistry(ctx, scope, registry)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	response["registry"] = newRegistryView(created, level)
+	response["message"] = "Registry credentials created; the password is write-only and cannot be read back"
+	return tm.jsonResult(response)
+}
+
+func (tm *ToolManager) handleUpdateRegistry(ctx context.Context, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if cancelled := checkContextCancelled(ctx); cancelled != nil {
+		return cancelled, nil
+	}
+
+	registry, err := getRegistryArg(arguments, false)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	level := getString(arguments, "scope", client.SecretLevelRepo)
+	scope, response, err := tm.getRegistryScope(ctx, arguments, level)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	updated, err := tm.client.UpdateRegistry(ctx, scope, registry)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	response["registry"] = newRegistryView(updated, level)
+	response["password_changed"] = registry.Password != ""
+	return tm.jsonResult(response)
+}
+
+func (tm *ToolManager) handleDeleteRegistry(ctx context.Context, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if cancelled := checkContextCancelled(ctx); cancelled != nil {
+		return cancelled, nil
+	}
+
+	address := getRegistryAddress(arguments)
+	if address == "" {
+		return tm.errorResult(client.Validationf("address is required")), nil
+	}
+
+	level := getString(arguments, "scope", client.SecretLevelRepo)
+	scope, response, err := tm.getRegistryScope(ctx, arguments, level)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	if err := tm.client.DeleteRegistry(ctx, scope, address); err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	response["address"] = address
+	response["deleted"] = true
+	return tm.jsonResult(response)
+}
+
+// imageCheck is one image of check_registry_images with the credentials used to pull it
+type imageCheck struct {
+	Image       string        `json:"image"`
+	Registry    string        `json:"registry,omitempty"`
+	Status      string        `json:"status"`
+	Credentials *registryView `json:"credentials,omitempty"`
+	UsedBy      []string      `json:"used_by"`
+}
+
+func (tm *ToolManager) handleCheckRegistryImages(ctx context.Context, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if cancelled := checkContextCancelled(ctx); cancelled != nil {
+		return cancelled, nil
+	}
+
+	files, images, err := readConfigImages(getString(arguments, "path", ""))
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	views, response, unavailable, err := tm.collectRegistries(ctx, arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	checks, unused := checkImages(images, views)
+
+	counts := map[string]int{imageHasCredentials: 0, imageAnonymous: 0, imageUnresolved: 0}
+	for _, check := range checks {
+		counts[check.Status]++
+	}
+
+	response["files"] = files
+	response["images"] = checks
+	response["summary"] = counts
+	response["registries"] = views
+	response["unused_registries"] = unused
+	if len(unavailable) > 0 {
+		response["unavailable"] = unavailable
+	}
+	response["hint"] = "Woodpecker cannot tell whether stored credentials still work. If pulling an image with status credentials fails with an authentication error, the credentials have probably expired; replace them with update_registry. Images with status anonymous are pulled without credentials and fail if they are private."
+	return tm.jsonResult(response)
+}
+
+// checkImages matches each distinct image against the active credentials for its
+// registry host and returns the addresses of active credentials no image uses
+func checkImages(images []configImage, views []registryView) ([]imageCheck, []string) {
+	active := make(map[string]*registryView, len(views))
+	for i := range views {
+		if views[i].Active != nil && *views[i].Active {
+			active[normalizeRegistryAddress(views[i].Address)] = &views[i]
+		}
+	}
+
+	used := map[string]bool{}
+	checks := []imageCheck{}
+	index := map[string]int{}
+	for _, image := range images {
+		if i, ok := index[image.Image]; ok {
+			checks[i].UsedBy = append(checks[i].UsedBy, image.usedBy())
+			continue
+		}
+
+		check := imageCheck{Image: image.Image, UsedBy: []string{image.usedBy()}}
+		if isTemplatedImage(image.Image) {
+			check.Status = imageUnresolved
+		} else {
+			check.Registry = imageRegistry(image.Image)
+			check.Status = imageAnonymous
+			if credentials, ok := active[check.Registry]; ok {
+				check.Status = imageHasCredentials
+				check.Credentials = credentials
+				used[check.Registry] = true
+			}
+		}
+
+		index[image.Image] = len(checks)
+		checks = append(checks, check)
+	}
+
+	unused := []string{}
+	for host, view := range active {
+		if !used[host] {
+			unused = append(unused, view.Address)
+		}
+	}
+	slices.Sort(unused)
+	return checks, unused
+}
+
+// getRegistryAddress reads the address argument, dropping a scheme or trailing
+// slash, which Woodpecker would not match against image names
+func getRegistryAddress(arguments map[string]interface{}) string {
+	address := strings.TrimSpace(getString(arguments, "address", ""))
+	if _, rest, ok := strings.Cut(address, "://"); ok {
+		address = rest
+	}
+	return strings.TrimRight(address, "/")
+}
+
+// getRegistryArg builds registry credentials from address, username and password.
+// On create all three are required; on update omitted fields keep their current
+// value, but something has to change.
+func getRegistryArg(arguments map[string]interface{}, create bool) (*woodpecker.Registry, error) {
+	registry := &woodpecker.Registry{
+		Address:  getRegistryAddress(arguments),
+		Username: strings.TrimSpace(getString(arguments, "username", "")),
+		Password: getString(arguments, "password", ""),
+	}
+	if registry.Address == "" {
+		return nil, client.Validationf("address is required")
+	}
+
+	if create {
+		if registry.Username == "" {
+			return nil, client.Validationf("username is required")
+		}
+		if registry.Password == "" {
+			return nil, client.Validationf("password is required")
+		}
+	} else if registry.Username == "" && registry.Password == "" {
+		return nil, client.Validationf("nothing to update; pass username or password")
+	}
+
+	return registry, nil
+}
@@ -0,0 +1,169 @@
+package tools
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+)
+
+func TestImageRegistry(t *testing.T) {
+	tests := map[string]string{
+		"alpine":                          "docker.io",
+		"golang:1.24":                     "docker.io",
+		"woodpeckerci/plugin-docker":      "docker.io",
+		"docker.io/library/alpine":        "docker.io",
+		"index.docker.io/library/alpine":  "docker.io",
+		"ghcr.io/acme/builder:latest":     "ghcr.io",
+		"registry.example.com:5000/app":   "registry.example.com:5000",
+		"localhost/app":                   "localhost",
+		"GHCR.IO/acme/builder@sha256:abc": "ghcr.io",
+	}
+	for image, want := range tests {
+		require.Equal(t, want, imageRegistry(image), image)
+	}
+
+	require.Equal(t, "docker.io", normalizeRegistryAddress("https://index.docker.io/v1/"))
+	require.Equal(t, "ghcr.io", normalizeRegistryAddress("ghcr.io"))
+}
+
+func TestWorkflowImages(t *testing.T) {
+	config := []byte(`
+clone:
+  git:
+    image: ghcr.io/acme/git
+services:
+  - name: db
+    image: postgres:16
+steps:
+  test:
+    image: golang:1.24
+  build:
+    image: ghcr.io/acme/builder
+  notify:
+    commands: [echo done]
+  deploy:
+    image: ${REGISTRY}/deployer
+`)
+
+	images, err := workflowImages("ci.yaml", config)
+	require.NoError(t, err)
+
+	var found []string
+	for _, image := range images {
+		found = append(found, image.usedBy()+" "+image.Image)
+	}
+	require.Equal(t, []string{
+		"ci.yaml: clone.git ghcr.io/acme/git",
+		"ci.yaml: services.db postgres:16",
+		"ci.yaml: steps.test golang:1.24",
+		"ci.yaml: steps.build ghcr.io/acme/builder",
+		"ci.yaml: steps.deploy ${REGISTRY}/deployer",
+	}, found)
+
+	_, err = workflowImages("bad.yaml", []byte("steps: [\n"))
+	require.ErrorIs(t, err, client.ErrValidation)
+}
+
+func TestReadConfigImages_Directory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "build.yaml"), []byte("steps:\n  build:\n    image: golang\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lint.yml"), []byte("steps:\n  - name: lint\n    image: golangci/golangci-lint\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a workflow"), 0o600))
+
+	files, images, err := readConfigImages(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.Len(t, images, 2)
+	require.Equal(t, "lint", images[1].Step)
+
+	_, _, err = readConfigImages(filepath.Join(dir, "README.md"))
+	require.ErrorIs(t, err, client.ErrValidation)
+}
+
+func TestCheckImages(t *testing.T) {
+	views := []registryView{
+		{Address: "ghcr.io", Username: "repo-bot", Scope: client.SecretLevelRepo},
+		{Address: "ghcr.io", Username: "org-bot", Scope: client.SecretLevelOrg},
+		{Address: "https://index.docker.io/v1/", Username: "hub", Scope: client.SecretLevelOrg},
+		{Address: "quay.io", Username: "quay", Scope: client.SecretLevelOrg},
+	}
+	resolveRegistries(views)
+	require.Equal(t, client.SecretLevelRepo, views[1].OverriddenBy)
+
+	images := []configImage{
+		{Image: "ghcr.io/acme/builder", File: "ci.yaml", Section: "steps", Step: "build"},
+		{Image: "ghcr.io/acme/builder", File: "ci.yaml", Section: "steps", Step: "release"},
+		{Image: "alpine", File: "ci.yaml", Section: "steps", Step: "test"},
+		{Image: "registry.example.com/app", File: "ci.yaml", Section: "services", Step: "app"},
+		{Image: "${REGISTRY}/deployer", File: "ci.yaml", Section: "steps", Step: "deploy"},
+	}
+
+	checks, unused := checkImages(images, views)
+	require.Len(t, checks, 4)
+
+	require.Equal(t, imageHasCredentials, checks[0].Status)
+	require.Equal(t, "repo-bot", checks[0].Credentials.Username)
+	require.Equal(t, []string{"ci.yaml: steps.build", "ci.yaml: steps.release"}, checks[0].UsedBy)
+
+	require.Equal(t, imageHasCredentials, checks[1].Status)
+	require.Equal(t, "docker.io", checks[1].Registry)
+
+	require.Equal(t, imageAnonymous, checks[2].Status)
+	require.Nil(t, checks[2].Credentials)
+
+	require.Equal(t, imageUnresolved, checks[3].Status)
+	require.Empty(t, checks[3].Registry)
+
+	require.Equal(t, []string{"quay.io"}, unused)
+}
+
+func TestRegistryView_OmitsPassword(t *testing.T) {
+	view := newRegistryView(&woodpecker.Registry{ID: 1, Address: "ghcr.io", Username: "bot", Password: "hunter2"}, client.SecretLevelRepo)
+
+	encoded, err := json.Marshal(view)
+	require.NoError(t, err)
+	require.NotContains(t, string(encoded), "hunter2")
+	require.NotContains(t, string(encoded), "password")
+}
+
+func TestGetRegistryArg(t *testing.T) {
+	registry, err := getRegistryArg(map[string]interface{}{
+		"address":  "https://ghcr.io/",
+		"username": "bot",
+		"password": "token",
+	}, true)
+	require.NoError(t, err)
+	require.Equal(t, "ghcr.io", registry.Address)
+
+	_, err = getRegistryArg(map[string]interface{}{"address": "ghcr.io", "username": "bot"}, true)
+	require.ErrorIs(t, err, client.ErrValidation)
+
+	_, err = getRegistryArg(map[string]interface{}{"address": "ghcr.io"}, false)
+	require.ErrorIs(t, err, client.ErrValidation)
+
+	registry, err = getRegistryArg(map[string]interface{}{"address": "ghcr.io", "password": "new"}, false)
+	require.NoError(t, err)
+	require.Empty(t, registry.Username)
+}
+
+func TestRegistryTools_RejectGlobalScope(t *testing.T) {
+	tm := newTestToolManager(t)
+
+	result := callTool(t, tm, "create_registry", map[string]interface{}{
+		"repo_id":  float64(7),
+		"scope":    client.SecretLevelGlobal,
+		"address":  "ghcr.io",
+		"username": "bot",
+		"password": "token",
+	})
+
+	require.True(t, result.IsError)
+	require.Contains(t, result.Content[0].(mcp.TextContent).Text, "scope must be repo or org")
+}
@@ -0,0 +1,224 @@
+package tools
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+)
+
+const (
+	defaultSearchContextLines = 2
+	maxSearchContextLines     = 20
+	defaultSearchMaxMatches   = 50
+	maxSearchMaxMatches       = 500
+	defaultSearchMaxBytes     = 16 * 1024
+	maxSearchMaxBytes         = 64 * 1024
+)
+
+// logMatch is one matching line with its surrounding context
+type logMatch struct {
+	Workflow string    `json:"workflow"`
+	Step     string    `json:"step"`
+	StepID   int64     `json:"step_id"`
+	Line     int       `json:"line"`
+	Text     string    `json:"text"`
+	Before   []logLine `json:"before,omitempty"`
+	After    []logLine `json:"after,omitempty"`
+}
+
+// size approximates how much of the response budget the match uses
+func (m logMatch) size() int {
+	n := len(m.Text)
+	for _, l := range m.Before {
+		n += len(l.Text)
+	}
+	for _, l := range m.After {
+		n += len(l.Text)
+	}
+	return n
+}
+
+// stepRef identifies a step to search together with its workflow
+type stepRef struct {
+	workflow string
+	step     *woodpecker.Step
+}
+
+func (tm *ToolManager) handleSearchLogs(ctx context.Context, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if cancelled := checkContextCancelled(ctx); cancelled != nil {
+		return cancelled, nil
+	}
+
+	pattern := getString(arguments, "pattern", "")
+	if pattern == "" {
+		return tm.errorResult(client.Validationf("pattern is required")), nil
+	}
+	if getBool(arguments, "case_insensitive", false) {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return tm.errorResult(client.Validationf("invalid pattern: %v", err)), nil
+	}
+
+	repoID, err := getRepoID(ctx, tm.client, arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	pipeline, err := tm.getPipelineArg(ctx, repoID, arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	steps, err := selectSteps(pipeline.Workflows, arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	before := clampInt(int(getNumber(arguments, "before", defaultSearchContextLines)), 0, maxSearchContextLines)
+	after := clampInt(int(getNumber(arguments, "after", defaultSearchContextLines)), 0, maxSearchContextLines)
+	maxMatches := clampInt(int(getNumber(arguments, "max_matches", defaultSearchMaxMatches)), 1, maxSearchMaxMatches)
+	maxBytes := clampInt(int(getNumber(arguments, "max_match_bytes", defaultSearchMaxBytes)), 1, maxSearchMaxBytes)
+
+	matches := []logMatch{}
+	usedBytes := 0
+	truncated := false
+	searched := 0
+	// A step whose log cannot be fetched, e.g. one that was skipped, does not end the search
+	stepErrors := map[string]string{}
+	var lastErr error
+
+search:
+	for _, ref := range steps {
+		if cancelled := checkContextCancelled(ctx); cancelled != nil {
+			return cancelled, nil
+		}
+
+		entries, err := tm.client.GetStepLogs(ctx, repoID, pipeline.Number, ref.step.ID)
+		if err != nil {
+			stepErrors[ref.workflow+"/"+ref.step.Name] = err.Error()
+			lastErr = err
+			continue
+		}
+		searched++
+
+		for _, m := range searchLines(decodeLogLines(entries), re, before, after) {
+			if len(matches) == maxMatches || usedBytes+m.size() > maxBytes {
+				truncated = true
+				break search
+			}
+			m.Workflow = ref.workflow
+			m.Step = ref.step.Name
+			m.StepID = ref.step.ID
+			matches = append(matches, m)
+			usedBytes += m.size()
+		}
+	}
+
+	if searched == 0 && lastErr != nil {
+		return tm.errorResult(lastErr), nil
+	}
+
+	response := map[string]interface{}{
+		"repo_id":         repoID,
+		"pipeline_number": pipeline.Number,
+		"pattern":         pattern,
+		"steps_searched":  searched,
+		"steps_in_scope":  len(steps),
+		"matches":         matches,
+		"match_count":     len(matches),
+		"truncated":       truncated,
+	}
+	if len(stepErrors) > 0 {
+		response["errors"] = stepErrors
+	}
+	if truncated {
+		response["hint"] = "Results were capped by max_matches or max_match_bytes; narrow the pattern or scope, or raise the limits"
+	}
+
+	return tm.jsonResult(response)
+}
+
+// selectSteps picks the steps to search: one step by step_id or step_name, every
+// step of workflow_name, or every step of the pipeline
+func selectSteps(workflows []*woodpecker.Workflow, arguments map[string]interface{}) ([]stepRef, error) {
+	workflowName := getString(arguments, "workflow_name", "")
+
+	if _, ok := arguments["step_id"]; ok {
+		stepID, err := requireNumber(arguments, "step_id")
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range workflows {
+			for _, s := range w.Children {
+				if s.ID == int64(stepID) {
+					return []stepRef{{workflow: w.Name, step: s}}, nil
+				}
+			}
+		}
+		return nil, client.Validationf("step_id %d is not part of this pipeline; available steps: %s",
+			int64(stepID), strings.Join(availableSteps(workflows), ", "))
+	}
+
+	if stepName := getString(arguments, "step_name", ""); stepName != "" {
+		step, err := findStep(workflows, stepName, workflowName)
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range workflows {
+			for _, s := range w.Children {
+				if s == step {
+					return []stepRef{{workflow: w.Name, step: s}}, nil
+				}
+			}
+		}
+	}
+
+	var refs []stepRef
+	found := workflowName == ""
+	for _, w := range workflows {
+		if workflowName != "" && !strings.EqualFold(w.Name, workflowName) {
+			continue
+		}
+		found = true
+		for _, s := range w.Children {
+			refs = append(refs, stepRef{workflow: w.Name, step: s})
+		}
+	}
+	if !found {
+		var names []string
+		for _, w := range workflows {
+			names = append(names, w.Name)
+		}
+		return nil, client.Validationf("workflow %q not found; available workflows: %s", workflowName, strings.Join(names, ", "))
+	}
+
+	return refs, nil
+}
+
+// searchLines returns every line matching re with up to before/after lines of context
+func searchLines(lines []logLine, re *regexp.Regexp, before, after int) []logMatch {
+	var matches []logMatch
+	for i, line := range lines {
+		if !re.MatchString(line.Text) {
+			continue
+		}
+		matches = append(matches, logMatch{
+			Line:   line.Number,
+			Text:   line.Text,
+			Before: lines[max(i-before, 0):i],
+			After:  lines[i+1 : min(i+1+after, len(lines))],
+		})
+	}
+	return matches
+}
+
+func clampInt(v, lo, hi int) int {
+	return min(max(v, lo), hi)
+}
@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"encoding/base64"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+)
+
+func TestSearchLines(t *testing.T) {
+	lines := numberedLines("a", "panic: boom", "b", "c", "panic: again")
+
+	matches := searchLines(lines, regexp.MustCompile(`^panic`), 1, 2)
+	require.Len(t, matches, 2)
+
+	require.Equal(t, 1, matches[0].Line)
+	require.Equal(t, []logLine{{Number: 0, Text: "a"}}, matches[0].Before)
+	require.Equal(t, []logLine{{Number: 2, Text: "b"}, {Number: 3, Text: "c"}}, matches[0].After)
+
+	require.Equal(t, 4, matches[1].Line)
+	require.Len(t, matches[1].Before, 1)
+	require.Empty(t, matches[1].After)
+	require.Equal(t, len("panic: again")+len("c"), matches[1].size())
+}
+
+func TestSelectSteps(t *testing.T) {
+	workflows := []*woodpecker.Workflow{
+		{Name: "build", Children: []*woodpecker.Step{{ID: 1, Name: "clone"}, {ID: 2, Name: "test"}}},
+		{Name: "lint", Children: []*woodpecker.Step{{ID: 3, Name: "vet"}}},
+	}
+
+	refs, err := selectSteps(workflows, map[string]interface{}{})
+	require.NoError(t, err)
+	require.Len(t, refs, 3)
+
+	refs, err = selectSteps(workflows, map[string]interface{}{"workflow_name": "build"})
+	require.NoError(t, err)
+	require.Len(t, refs, 2)
+
+	refs, err = selectSteps(workflows, map[string]interface{}{"step_name": "vet"})
+	require.NoError(t, err)
+	require.Equal(t, "lint", refs[0].workflow)
+	require.Equal(t, int64(3), refs[0].step.ID)
+
+	refs, err = selectSteps(workflows, map[string]interface{}{"step_id": float64(2)})
+	require.NoError(t, err)
+	require.Equal(t, "test", refs[0].step.Name)
+
+	_, err = selectSteps(workflows, map[string]interface{}{"step_id": float64(9)})
+	require.ErrorIs(t, err, client.ErrValidation)
+
+	_, err = selectSteps(workflows, map[string]interface{}{"workflow_name": "deploy"})
+	require.ErrorIs(t, err, client.ErrValidation)
+	require.Contains(t, err.Error(), "available workflows: build, lint")
+}
+
+func TestSearchLogs_KeepsSearchingPastStepErrors(t *testing.T) {
+	log := base64.StdEncoding.EncodeToString([]byte("panic: boom"))
+	tm := newFakeWoodpecker(t, map[string]http.HandlerFunc{
+		"/api/repos/1/pipelines/4": jsonRoute(`{"number": 4, "workflows": [{"name": "build", "children": [{"id": 3, "name": "test", "state": "failure"}, {"id": 5, "name": "deploy", "state": "skipped"}]}]}`),
+		"/api/repos/1/logs/4/3":    jsonRoute(`[{"line": 0, "data": "` + log + `"}]`),
+	})
+
+	result := callTool(t, tm, "search_logs", map[string]interface{}{"repo_id": float64(1), "pipeline_number": float64(4), "pattern": "panic"})
+	require.False(t, result.IsError)
+	body := resultJSON(t, result)
+	require.EqualValues(t, 1, body["match_count"])
+	require.EqualValues(t, 1, body["steps_searched"])
+	require.Contains(t, body["errors"], "build/deploy")
+
+	// Only fails when no step could be searched
+	result = callTool(t, tm, "search_logs", map[string]interface{}{"repo_id": float64(1), "pipeline_number": float64(4), "step_id": float64(5), "pattern": "panic"})
+	require.True(t, result.IsError)
+	require.Equal(t, client.CodeNotFound, decodeToolError(t, result).Code)
+}
@@ -0,0 +1,330 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+)
+
+// secretEvents are the pipeline events a secret can be exposed to
+var secretEvents = []string{"push", "tag", "pull_request", "pull_request_closed", "deployment", "cron", "manual", "release"}
+
+// defaultSecretEvents leaves out pull requests, which may run code from forks
+var defaultSecretEvents = []string{"push", "tag", "deployment", "cron", "manual"}
+
+// secretScopeAll lists every level a repository's pipelines see secrets from
+const secretScopeAll = "all"
+
+// scopedTools take a scope argument choosing between repository, organization
+// and global objects. For every other tool scope means nothing, and the call acts
+// on a repository.
+var scopedTools = map[string]bool{
+	"list_secrets":    true,
+	"create_secret":   true,
+	"update_secret":   true,
+	"delete_secret":   true,
+	"list_registries": true,
+	"create_registry": true,
+	"update_registry": true,
+	"delete_registry": true,
+}
+
+// getToolScope returns the scope argument of a scoped tool, or "" for a call that
+// acts on a repository
+func getToolScope(tool string, arguments map[string]interface{}) string {
+	if !scopedTools[tool] {
+		return ""
+	}
+	return getString(arguments, "scope", "")
+}
+
+// secretView is a secret as returned by the secret tools. It has no value field:
+// values are write-only.
+type secretView struct {
+	ID     int64    `json:"id"`
+	Name   string   `json:"name"`
+	Scope  string   `json:"scope"`
+	Events []string `json:"events"`
+	Images []string `json:"images"`
+	// Active and OverriddenBy are only set when several levels are listed together
+	Active       *bool  `json:"active,omitempty"`
+	OverriddenBy string `json:"overridden_by,omitempty"`
+}
+
+func newSecretView(s *woodpecker.Secret, level string) secretView {
+	view := secretView{ID: s.ID, Name: s.Name, Scope: level, Events: s.Events, Images: s.Images}
+	if view.Events == nil {
+		view.Events = []string{}
+	}
+	if view.Images == nil {
+		view.Images = []string{}
+	}
+	return view
+}
+
+// resolveSecrets marks which definition of each name a pipeline uses. views must
+// be ordered from the most specific level: Woodpecker prefers repository secrets
+// over organization secrets over global secrets with the same name.
+func resolveSecrets(views []secretView) {
+	winners := make(map[string]string, len(views))
+	for i := range views {
+		active := true
+		if level, ok := winners[views[i].Name]; ok {
+			active = false
+			views[i].OverriddenBy = level
+		} else {
+			winners[views[i].Name] = views[i].Scope
+		}
+		views[i].Active = &active
+	}
+}
+
+// getOrgArg returns the organization named by org or, failing that, the owner of
+// the repository the arguments name. Like getRepoID, it stores the result back
+// into arguments so later steps of the call act on the same organization.
+func (tm *ToolManager) getOrgArg(ctx context.Context, arguments map[string]interface{}) (string, error) {
+	org := getString(arguments, "org", "")
+	if org == "" {
+		fullName := getString(arguments, "repo_name", "")
+		if fullName == "" {
+			repoID, err := getRepoID(ctx, tm.client, arguments)
+			if err != nil {
+				return "", err
+			}
+			repo, err := tm.client.GetRepository(ctx, repoID)
+			if err != nil {
+				return "", err
+			}
+			fullName = repo.FullName
+		}
+
+		owner, _, ok := strings.Cut(fullName, "/")
+		if !ok || owner == "" {
+			return "", client.Validationf("cannot tell the organization from repository %q; pass org", fullName)
+		}
+		org = owner
+	}
+
+	arguments["org"] = org
+	return org, nil
+}
+
+// getSecretScope resolves the scope argument to where secrets are read or written,
+// together with the fields identifying it in responses
+func (tm *ToolManager) getSecretScope(ctx context.Context, arguments map[string]interface{}, level string) (client.SecretScope, map[string]interface{}, error) {
+	switch level {
+	case client.SecretLevelRepo:
+		repoID, err := getRepoID(ctx, tm.client, arguments)
+		if err != nil {
+			return client.SecretScope{}, nil, err
+		}
+		return client.RepoSecrets(repoID), map[string]interface{}{"scope": level, "repo_id": repoID}, nil
+	case client.SecretLevelOrg:
+		name, err := tm.getOrgArg(ctx, arguments)
+		if err != nil {
+			return client.SecretScope{}, nil, err
+		}
+		org, err := tm.client.LookupOrg(ctx, name)
+		if err != nil {
+			return client.SecretScope{}, nil, err
+		}
+		return client.OrgSecrets(org.ID), map[string]interface{}{"scope": level, "org": org.Name, "org_id": org.ID}, nil
+	case client.SecretLevelGlobal:
+		return client.GlobalSecrets(), map[string]interface{}{"scope": level}, nil
+	}
+	return client.SecretScope{}, nil, client.Validationf("scope must be repo, org or global, got %q", level)
+}
+
+func (tm *ToolManager) handleListSecrets(ctx context.Context, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if cancelled := checkContextCancelled(ctx); cancelled != nil {
+		return cancelled, nil
+	}
+
+	level := getString(arguments, "scope", secretScopeAll)
+	if level != secretScopeAll {
+		scope, response, err := tm.getSecretScope(ctx, arguments, level)
+		if err != nil {
+			return tm.errorResult(err), nil
+		}
+
+		secrets, err := tm.client.ListSecrets(ctx, scope)
+		if err != nil {
+			return tm.errorResult(err), nil
+		}
+
+		views := make([]secretView, 0, len(secrets))
+		for _, s := range secrets {
+			views = append(views, newSecretView(s, level))
+		}
+		response["secrets"] = views
+		response["count"] = len(views)
+		return tm.jsonResult(response)
+	}
+
+	response := map[string]interface{}{"scope": secretScopeAll}
+	views := []secretView{}
+	unavailable := map[string]string{}
+
+	for _, level := range []string{client.SecretLevelRepo, client.SecretLevelOrg, client.SecretLevelGlobal} {
+		scope, fields, err := tm.getSecretScope(ctx, arguments, level)
+		var secrets []*woodpecker.Secret
+		if err == nil {
+			secrets, err = tm.client.ListSecrets(ctx, scope)
+		}
+		if err != nil {
+			// The repository level is required; the others may simply be off limits to this token
+			if level == client.SecretLevelRepo || !(errors.Is(err, client.ErrForbidden) || errors.Is(err, client.ErrNotFound) || errors.Is(err, client.ErrUnauthorized)) {
+				return tm.errorResult(err), nil
+			}
+			unavailable[level] = err.Error()
+			continue
+		}
+
+		for k, v := range fields {
+			if k != "scope" {
+				response[k] = v
+			}
+		}
+		for _, s := range secrets {
+			views = append(views, newSecretView(s, level))
+		}
+	}
+
+	resolveSecrets(views)
+	response["secrets"] = views
+	response["count"] = len(views)
+	if len(unavailable) > 0 {
+		response["unavailable"] = unavailable
+	}
+	return tm.jsonResult(response)
+}
+
+func (tm *ToolManager) handleCreateSecret(ctx context.Context, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if cancelled := checkContextCancelled(ctx); cancelled != nil {
+		return cancelled, nil
+	}
+
+	secret, err := getSecretArg(arguments, true)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	level := getString(arguments, "scope", client.SecretLevelRepo)
+	scope, response, err := tm.getSecretScope(ctx, arguments, level)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	created, err := tm.client.CreateSecret(ctx, scope, secret)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	response["secret"] = newSecretView(created, level)
+	response["message"] = "Secret created; its value is write-only and cannot be read back"
+	return tm.jsonResult(response)
+}
+
+func (tm *ToolManager) handleUpdateSecret(ctx context.Context, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if cancelled := checkContextCancelled(ctx); cancelled != nil {
+		return cancelled, nil
+	}
+
+	secret, err := getSecretArg(arguments, false)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	level := getString(arguments, "scope", client.SecretLevelRepo)
+	scope, response, err := tm.getSecretScope(ctx, arguments, level)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	updated, err := tm.client.UpdateSecret(ctx, scope, secret)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	response["secret"] = newSecretView(updated, level)
+	response["value_changed"] = secret.Value != ""
+	return tm.jsonResult(response)
+}
+
+func (tm *ToolManager) handleDeleteSecret(ctx context.Context, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if cancelled := checkContextCancelled(ctx); cancelled != nil {
+		return cancelled, nil
+	}
+
+	name := getString(arguments, "name", "")
+	if name == "" {
+		return tm.errorResult(client.Validationf("name is required")), nil
+	}
+
+	level := getString(arguments, "scope", client.SecretLevelRepo)
+	scope, response, err := tm.getSecretScope(ctx, arguments, level)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	if err := tm.client.DeleteSecret(ctx, scope, name); err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	response["name"] = name
+	response["deleted"] = true
+	return tm.jsonResult(response)
+}
+
+// getSecretArg builds a secret from name, value, events and images. On create the
+// value is required and events default to defaultSecretEvents; on update every
+// omitted field keeps its current setting, but something has to change.
+func getSecretArg(arguments map[string]interface{}, create bool) (*woodpecker.Secret, error) {
+	secret := &woodpecker.Secret{
+		Name:  strings.TrimSpace(getString(arguments, "name", "")),
+		Value: getString(arguments, "value", ""),
+	}
+	if secret.Name == "" {
+		return nil, client.Validationf("name is required")
+	}
+
+	events, err := getStringList(arguments, "events")
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		if !slices.Contains(secretEvents, event) {
+			return nil, client.Validationf("unknown event %q; valid events are %s", event, strings.Join(secretEvents, ", "))
+		}
+	}
+
+	images, err := getStringList(arguments, "images")
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := arguments["images"]; ok && images == nil {
+		// An explicit empty list removes the image restriction
+		images = []string{}
+	}
+
+	if create {
+		if secret.Value == "" {
+			return nil, client.Validationf("value is required")
+		}
+		if len(events) == 0 {
+			events = defaultSecretEvents
+		}
+	} else if secret.Value == "" && events == nil && images == nil {
+		return nil, client.Validationf("nothing to update; pass value, events or images")
+	}
+
+	secret.Events = events
+	secret.Images = images
+	return secret, nil
+}
@@ -0,0 +1,174 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/config"
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/policy"
+)
+
+func TestGetSecretArg_Create(t *testing.T) {
+	secret, err := getSecretArg(map[string]interface{}{
+		"name":  " docker_password ",
+		"value": "hunter2",
+	}, true)
+	require.NoError(t, err)
+	require.Equal(t, "docker_password", secret.Name)
+	require.Equal(t, "hunter2", secret.Value)
+	require.Equal(t, defaultSecretEvents, secret.Events)
+	require.NotContains(t, secret.Events, "pull_request")
+
+	_, err = getSecretArg(map[string]interface{}{"name": "token"}, true)
+	require.ErrorIs(t, err, client.ErrValidation)
+
+	_, err = getSecretArg(map[string]interface{}{"name": "token", "value": "x", "events": []interface{}{"merge"}}, true)
+	require.ErrorIs(t, err, client.ErrValidation)
+}
+
+func TestGetSecretArg_Update(t *testing.T) {
+	_, err := getSecretArg(map[string]interface{}{"name": "token"}, false)
+	require.ErrorIs(t, err, client.ErrValidation)
+
+	secret, err := getSecretArg(map[string]interface{}{"name": "token", "events": "push,tag"}, false)
+	require.NoError(t, err)
+	require.Empty(t, secret.Value)
+	require.Equal(t, []string{"push", "tag"}, secret.Events)
+	require.Nil(t, secret.Images)
+
+	secret, err = getSecretArg(map[string]interface{}{"name": "token", "images": []interface{}{}}, false)
+	require.NoError(t, err)
+	require.NotNil(t, secret.Images)
+	require.Empty(t, secret.Images)
+}
+
+func TestSecretView_OmitsValue(t *testing.T) {
+	data, err := json.Marshal(newSecretView(&woodpecker.Secret{ID: 1, Name: "token", Value: "hunter2"}, client.SecretLevelRepo))
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "hunter2")
+	require.NotContains(t, string(data), "value")
+	require.Contains(t, string(data), `"events":[]`)
+}
+
+func TestGetToolHandler_DebugLogMasksSecretValue(t *testing.T) {
+	var logs bytes.Buffer
+	logger := logrus.New()
+	logger.SetLevel(logrus.DebugLevel)
+	logger.SetOutput(&logs)
+
+	tm := NewToolManager(nil, logger)
+	// Fails validation before any Woodpecker call is made
+	result := callTool(t, tm, "create_secret", map[string]interface{}{"repo_id": float64(1), "value": "hunter2"})
+
+	require.True(t, result.IsError)
+	require.Contains(t, logs.String(), "Calling tool")
+	require.NotContains(t, logs.String(), "hunter2")
+}
+
+func TestToolFilter_ReadOnlyHidesSecretChanges(t *testing.T) {
+	tm := NewToolManager(nil, logrus.New())
+	tm.SetToolFilter(ToolFilter{ReadOnly: true})
+
+	var names []string
+	for _, st := range tm.GetServerTools() {
+		names = append(names, st.Tool.Name)
+	}
+	require.Contains(t, names, "list_secrets")
+	for _, name := range []string{"create_secret", "update_secret", "delete_secret"} {
+		require.NotContains(t, names, name)
+	}
+
+	var tool mcp.Tool
+	for _, t := range tm.tools {
+		if t.Name == "delete_secret" {
+			tool = t
+		}
+	}
+	require.True(t, *tool.Annotations.DestructiveHint)
+}
+
+func TestResolveSecrets_MostSpecificLevelWins(t *testing.T) {
+	views := []secretView{
+		{Name: "docker_password", Scope: client.SecretLevelRepo},
+		{Name: "docker_password", Scope: client.SecretLevelOrg},
+		{Name: "npm_token", Scope: client.SecretLevelOrg},
+		{Name: "npm_token", Scope: client.SecretLevelGlobal},
+		{Name: "slack_webhook", Scope: client.SecretLevelGlobal},
+	}
+
+	resolveSecrets(views)
+
+	active := map[string]string{}
+	for _, v := range views {
+		if *v.Active {
+			active[v.Name] = v.Scope
+		}
+	}
+	require.Equal(t, map[string]string{
+		"docker_password": client.SecretLevelRepo,
+		"npm_token":       client.SecretLevelOrg,
+		"slack_webhook":   client.SecretLevelGlobal,
+	}, active)
+	require.Equal(t, client.SecretLevelRepo, views[1].OverriddenBy)
+	require.Equal(t, client.SecretLevelOrg, views[3].OverriddenBy)
+}
+
+func TestGetOrgArg(t *testing.T) {
+	tm := newTestToolManager(t)
+	ctx := context.Background()
+
+	arguments := map[string]interface{}{"org": "acme", "repo_name": "other/repo"}
+	org, err := tm.getOrgArg(ctx, arguments)
+	require.NoError(t, err)
+	require.Equal(t, "acme", org)
+
+	org, err = tm.getOrgArg(ctx, map[string]interface{}{"repo_name": "acme/api"})
+	require.NoError(t, err)
+	require.Equal(t, "acme", org)
+
+	// Falls back to the owner of the repository found by ID, and pins it
+	arguments = map[string]interface{}{"repo_id": float64(7)}
+	org, err = tm.getOrgArg(ctx, arguments)
+	require.NoError(t, err)
+	require.Equal(t, "org", org)
+	require.Equal(t, "org", arguments["org"])
+
+	_, err = tm.getOrgArg(ctx, map[string]interface{}{"repo_name": "no-owner"})
+	require.ErrorIs(t, err, client.ErrValidation)
+}
+
+func TestEnforcePolicy_GlobalSecretsDoNotMatchRepoAllowRules(t *testing.T) {
+	p, err := policy.New(config.PolicyConfig{
+		Default: policy.EffectDeny,
+		Rules:   []config.PolicyRule{{Effect: policy.EffectAllow, Repos: []string{"org/*"}}},
+	})
+	require.NoError(t, err)
+	tm := newTestToolManager(t)
+	tm.SetPolicy(p)
+
+	tool := mcp.Tool{Name: "create_secret", Annotations: mutatingTool(false)}
+	require.NoError(t, tm.enforcePolicy(context.Background(), tool, map[string]interface{}{"repo_id": float64(7)}))
+
+	err = tm.enforcePolicy(context.Background(), tool, map[string]interface{}{"scope": "global"})
+	require.ErrorIs(t, err, client.ErrPolicyDenied)
+
+	err = tm.enforcePolicy(context.Background(), tool, map[string]interface{}{"scope": "org", "repo_id": float64(7)})
+	require.ErrorIs(t, err, client.ErrPolicyDenied)
+	require.Contains(t, err.Error(), "org org")
+}
+
+func TestDescribeAction_GlobalSecret(t *testing.T) {
+	tm := NewToolManager(nil, logrus.New())
+
+	action, err := tm.describeAction(context.Background(), "delete_secret", 0, map[string]interface{}{"scope": "global", "name": "npm_token"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"tool": "delete_secret", "scope": "global", "name": "npm_token"}, action)
+}
@@ -0,0 +1,264 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+)
+
+const (
+	verbosityMinimal  = "minimal"
+	verbosityStandard = "standard"
+	verbosityFull     = "full"
+)
+
+// shapeOptions are the per-call response shaping arguments accepted by every tool
+type shapeOptions struct {
+	Verbosity string
+	// Fields, when set, replaces the verbosity projection of Woodpecker objects
+	Fields []string
+	// MaxBytes caps the serialised response; 0 means unlimited
+	MaxBytes int
+}
+
+// summaryFields lists the JSON keys kept for each Woodpecker type per verbosity;
+// full keeps the upstream object unchanged
+var summaryFields = map[string]map[string][]string{
+	"repo": {
+		verbosityMinimal:  {"id", "full_name"},
+		verbosityStandard: {"id", "full_name", "default_branch", "forge_url", "visibility", "private", "trusted", "active"},
+	},
+	"pipeline": {
+		verbosityMinimal:  {"number", "status", "event", "branch"},
+		verbosityStandard: {"id", "number", "status", "event", "branch", "ref", "commit", "message", "author", "deploy_to", "created", "started", "finished", "forge_url"},
+	},
+}
+
+// getShapeOptions reads verbosity, fields and max_bytes from arguments
+func getShapeOptions(arguments map[string]interface{}) (shapeOptions, error) {
+	fields, err := getStringList(arguments, "fields")
+	if err != nil {
+		return shapeOptions{}, err
+	}
+
+	opts := shapeOptions{
+		Verbosity: getString(arguments, "verbosity", verbosityStandard),
+		Fields:    fields,
+		MaxBytes:  int(getNumber(arguments, "max_bytes", 0)),
+	}
+
+	switch opts.Verbosity {
+	case verbosityMinimal, verbosityStandard, verbosityFull:
+	default:
+		return opts, client.Validationf("verbosity must be minimal, standard or full, got %q", opts.Verbosity)
+	}
+	if opts.MaxBytes < 0 {
+		return opts, client.Validationf("max_bytes must not be negative")
+	}
+
+	return opts, nil
+}
+
+// shapeValue replaces Woodpecker objects anywhere in v with their projection
+func shapeValue(v interface{}, opts shapeOptions) interface{} {
+	if opts.Verbosity == verbosityFull && len(opts.Fields) == 0 {
+		return v
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		shaped := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			shaped[k] = shapeValue(item, opts)
+		}
+		return shaped
+	case []interface{}:
+		shaped := make([]interface{}, len(v))
+		for i, item := range v {
+			shaped[i] = shapeValue(item, opts)
+		}
+		return shaped
+	case *woodpecker.Repo:
+		return project(v, "repo", opts)
+	case []*woodpecker.Repo:
+		return projectAll(v, "repo", opts)
+	case *woodpecker.Pipeline:
+		return project(v, "pipeline", opts)
+	case []*woodpecker.Pipeline:
+		return projectAll(v, "pipeline", opts)
+	}
+	return v
+}
+
+func projectAll[T any](items []*T, kind string, opts shapeOptions) []interface{} {
+	projected := make([]interface{}, len(items))
+	for i, item := range items {
+		projected[i] = project(item, kind, opts)
+	}
+	return projected
+}
+
+// project keeps the curated or requested keys of obj's JSON form
+func project[T any](obj *T, kind string, opts shapeOptions) interface{} {
+	if obj == nil {
+		return nil
+	}
+
+	keys := opts.Fields
+	if len(keys) == 0 {
+		keys = summaryFields[kind][opts.Verbosity]
+	}
+	if len(keys) == 0 {
+		return obj
+	}
+
+	encoded, err := json.Marshal(obj)
+	if err != nil {
+		return obj
+	}
+	var full map[string]interface{}
+	if err := json.Unmarshal(encoded, &full); err != nil {
+		return obj
+	}
+
+	projected := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		if value, ok := full[key]; ok {
+			projected[key] = value
+		}
+	}
+	return projected
+}
+
+// truncationHint tells the agent how to get the rest of a truncated response
+const truncationHint = "Response exceeded max_bytes. Request fewer items (limit, lines), a lower verbosity or fewer fields, continue with next_cursor where offered, or raise max_bytes."
+
+// fitToBudget serialises data within maxBytes. It first drops trailing items of
+// the largest list and records what was omitted; if that is not enough the text
+// is cut and an explicit marker appended.
+func fitToBudget(data interface{}, maxBytes int) ([]byte, error) {
+	encoded, err := json.MarshalIndent(data, "", "  ")
+	if err != nil || maxBytes <= 0 || len(encoded) <= maxBytes {
+		return encoded, err
+	}
+
+	if shrunk, ok := shrinkLargestList(data, maxBytes); ok {
+		return shrunk, nil
+	}
+
+	return truncateText(encoded, maxBytes), nil
+}
+
+// truncateText cuts text to maxBytes on a character boundary and appends a marker
+func truncateText(text []byte, maxBytes int) []byte {
+	if maxBytes <= 0 || len(text) <= maxBytes {
+		return text
+	}
+
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+
+	marker := fmt.Sprintf("\n...[truncated: %d of %d bytes omitted] %s", len(text)-cut, len(text), truncationHint)
+	return append(text[:cut:cut], marker...)
+}
+
+// shrinkLargestList binary-searches how many items of the largest list in a
+// top-level object fit within maxBytes
+func shrinkLargestList(data interface{}, maxBytes int) ([]byte, bool) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, false
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(encoded, &object); err != nil {
+		return nil, false
+	}
+
+	field, largest := "", 0
+	for k, v := range object {
+		if list, ok := v.([]interface{}); ok {
+			if size := len(mustMarshal(list)); size > largest {
+				field, largest = k, size
+			}
+		}
+	}
+	if field == "" {
+		return nil, false
+	}
+
+	list := object[field].([]interface{})
+	render := func(n int) []byte {
+		object[field] = list[:n]
+		object["truncated"] = map[string]interface{}{
+			"field":    field,
+			"returned": n,
+			"omitted":  len(list) - n,
+			"hint":     truncationHint,
+		}
+		return mustMarshalIndent(object)
+	}
+
+	lo, hi := 0, len(list)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if len(render(mid)) <= maxBytes {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+
+	result := render(lo)
+	if len(result) > maxBytes {
+		return nil, false
+	}
+	return result, true
+}
+
+func mustMarshal(v interface{}) []byte {
+	encoded, _ := json.Marshal(v)
+	return encoded
+}
+
+func mustMarshalIndent(v interface{}) []byte {
+	encoded, _ := json.MarshalIndent(v, "", "  ")
+	return encoded
+}
+
+// withShapeArgs returns tool with the shaping arguments added to its schema
+func withShapeArgs(tool mcp.Tool) mcp.Tool {
+	properties := make(map[string]interface{}, len(tool.InputSchema.Properties)+3)
+	for k, v := range tool.InputSchema.Properties {
+		properties[k] = v
+	}
+	properties["verbosity"] = map[string]interface{}{
+		"type":        "string",
+		"enum":        []string{verbosityMinimal, verbosityStandard, verbosityFull},
+		"description": "How much of each Woodpecker object to return: minimal, standard (curated summary, default) or full (raw upstream object)",
+	}
+	properties["fields"] = map[string]interface{}{
+		"type":        "array",
+		"items":       map[string]interface{}{"type": "string"},
+		"description": "JSON keys to keep on each Woodpecker object, overriding verbosity",
+	}
+	properties["max_bytes"] = map[string]interface{}{
+		"type":        "number",
+		"description": "Truncate the response to about this many bytes, with a marker and continuation hint (default: unlimited)",
+	}
+	tool.InputSchema.Properties = properties
+	return tool
+}
+
+// shapedFor returns a copy of tm that renders results with opts
+func (tm *ToolManager) shapedFor(opts shapeOptions) *ToolManager {
+	scoped := *tm
+	scoped.shape = opts
+	return &scoped
+}
@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+)
+
+func resultJSON(t *testing.T, result *mcp.CallToolResult) map[string]interface{} {
+	t.Helper()
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &body))
+	return body
+}
+
+func TestGetShapeOptions(t *testing.T) {
+	opts, err := getShapeOptions(map[string]interface{}{})
+	require.NoError(t, err)
+	require.Equal(t, verbosityStandard, opts.Verbosity)
+	require.Zero(t, opts.MaxBytes)
+
+	opts, err = getShapeOptions(map[string]interface{}{"fields": "number,status", "max_bytes": float64(1000)})
+	require.NoError(t, err)
+	require.Equal(t, []string{"number", "status"}, opts.Fields)
+	require.Equal(t, 1000, opts.MaxBytes)
+
+	_, err = getShapeOptions(map[string]interface{}{"verbosity": "loud"})
+	require.ErrorIs(t, err, client.ErrValidation)
+}
+
+func TestShapeValue(t *testing.T) {
+	pipelines := []*woodpecker.Pipeline{
+		{ID: 1, Number: 7, Status: "failure", Event: "push", Branch: "main", Commit: "abc", Workflows: []*woodpecker.Workflow{{Name: "build"}}},
+	}
+	data := map[string]interface{}{"repo_id": int64(3), "pipelines": pipelines}
+
+	minimal := shapeValue(data, shapeOptions{Verbosity: verbosityMinimal}).(map[string]interface{})
+	require.Equal(t, int64(3), minimal["repo_id"])
+	require.Equal(t, []interface{}{map[string]interface{}{
+		"number": float64(7), "status": "failure", "event": "push", "branch": "main",
+	}}, minimal["pipelines"])
+
+	standard := shapeValue(data, shapeOptions{Verbosity: verbosityStandard}).(map[string]interface{})
+	first := standard["pipelines"].([]interface{})[0].(map[string]interface{})
+	require.Equal(t, "abc", first["commit"])
+	require.NotContains(t, first, "workflows")
+
+	fields := shapeValue(pipelines[0], shapeOptions{Verbosity: verbosityMinimal, Fields: []string{"commit", "workflows"}}).(map[string]interface{})
+	require.Len(t, fields, 2)
+	require.Contains(t, fields, "workflows")
+
+	require.Same(t, pipelines[0], shapeValue(pipelines[0], shapeOptions{Verbosity: verbosityFull}))
+}
+
+func TestFitToBudget_DropsListItems(t *testing.T) {
+	var items []interface{}
+	for i := 0; i < 100; i++ {
+		items = append(items, map[string]interface{}{"line": i, "text": strings.Repeat("x", 20)})
+	}
+	data := map[string]interface{}{"step_id": 4, "logs": items}
+
+	encoded, err := fitToBudget(data, 1000)
+	require.NoError(t, err)
+	require.LessOrEqual(t, len(encoded), 1000)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(encoded, &body))
+	truncated := body["truncated"].(map[string]interface{})
+	require.Equal(t, "logs", truncated["field"])
+	returned := int(truncated["returned"].(float64))
+	require.Len(t, body["logs"], returned)
+	require.Equal(t, float64(100-returned), truncated["omitted"])
+	require.NotEmpty(t, truncated["hint"])
+}
+
+func TestFitToBudget_CutsTextAsLastResort(t *testing.T) {
+	data := map[string]interface{}{"message": strings.Repeat("é", 500)}
+
+	encoded, err := fitToBudget(data, 100)
+	require.NoError(t, err)
+	require.Contains(t, string(encoded), "...[truncated:")
+	require.True(t, strings.HasPrefix(string(encoded), "{"))
+	require.NotContains(t, string(encoded), "�")
+
+	small, err := fitToBudget(data, 0)
+	require.NoError(t, err)
+	require.NotContains(t, string(small), "truncated")
+}
+
+func TestJSONResult_UsesCallShape(t *testing.T) {
+	tm := NewToolManager(nil, logrus.New()).shapedFor(shapeOptions{Verbosity: verbosityMinimal})
+
+	result, err := tm.jsonResult(&woodpecker.Repo{ID: 7, FullName: "org/app", Clone: "https://example.com/org/app.git"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"id": float64(7), "full_name": "org/app"}, resultJSON(t, result))
+}
+
+func TestGetServerTools_AddsShapeArguments(t *testing.T) {
+	tm := NewToolManager(nil, logrus.New())
+	for _, st := range tm.GetServerTools() {
+		for _, arg := range []string{"verbosity", "fields", "max_bytes"} {
+			require.Contains(t, st.Tool.InputSchema.Properties, arg, st.Tool.Name)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+)
+
+// stepNode is a step as returned by get_pipeline_steps
+type stepNode struct {
+	Name            string `json:"name"`
+	PID             int    `json:"pid"`
+	StepID          int64  `json:"step_id"`
+	State           string `json:"state"`
+	ExitCode        int    `json:"exit_code"`
+	Started         string `json:"started,omitempty"`
+	Finished        string `json:"finished,omitempty"`
+	DurationSeconds int64  `json:"duration_seconds"`
+	Failed          bool   `json:"failed"`
+	Error           string `json:"error,omitempty"`
+}
+
+// workflowNode is a workflow and its steps as returned by get_pipeline_steps
+type workflowNode struct {
+	Name            string     `json:"name"`
+	PID             int        `json:"pid"`
+	WorkflowID      int64      `json:"workflow_id"`
+	State           string     `json:"state"`
+	Started         string     `json:"started,omitempty"`
+	Finished        string     `json:"finished,omitempty"`
+	DurationSeconds int64      `json:"duration_seconds"`
+	Failed          bool       `json:"failed"`
+	Error           string     `json:"error,omitempty"`
+	Steps           []stepNode `json:"steps"`
+}
+
+// failedStepRef points at a failed step so its logs can be fetched directly
+type failedStepRef struct {
+	Workflow string `json:"workflow"`
+	Step     string `json:"step"`
+	StepID   int64  `json:"step_id"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// getPipelineArg fetches the pipeline named by pipeline_number, or the latest one when latest is set
+func (tm *ToolManager) getPipelineArg(ctx context.Context, repoID int64, arguments map[string]interface{}) (*woodpecker.Pipeline, error) {
+	if getBool(arguments, "latest", false) {
+		return tm.client.GetLastPipeline(ctx, repoID)
+	}
+
+	pipelineNum, err := requireNumber(arguments, "pipeline_number")
+	if err != nil {
+		return nil, err
+	}
+	return tm.client.GetPipeline(ctx, repoID, int64(pipelineNum))
+}
+
+func (tm *ToolManager) handleGetPipelineSteps(ctx context.Context, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if cancelled := checkContextCancelled(ctx); cancelled != nil {
+		return cancelled, nil
+	}
+
+	repoID, err := getRepoID(ctx, tm.client, arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	pipeline, err := tm.getPipelineArg(ctx, repoID, arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	workflows := buildStepTree(pipeline.Workflows, time.Now())
+
+	response := map[string]interface{}{
+		"repo_id":         repoID,
+		"pipeline_number": pipeline.Number,
+		"status":          pipeline.Status,
+		"workflows":       workflows,
+		"failed_steps":    failedSteps(workflows),
+	}
+
+	return tm.jsonResult(response)
+}
+
+// buildStepTree converts workflows into the get_pipeline_steps tree; durations of
+// unfinished steps are measured up to now
+func buildStepTree(workflows []*woodpecker.Workflow, now time.Time) []workflowNode {
+	nodes := make([]workflowNode, 0, len(workflows))
+	for _, w := range workflows {
+		node := workflowNode{
+			Name:            w.Name,
+			PID:             w.PID,
+			WorkflowID:      w.ID,
+			State:           string(w.State),
+			Started:         formatUnix(w.Started),
+			Finished:        formatUnix(w.Finished),
+			DurationSeconds: durationSeconds(w.Started, w.Finished, now),
+			Failed:          isFailedState(string(w.State)),
+			Error:           w.Error,
+			Steps:           make([]stepNode, 0, len(w.Children)),
+		}
+
+		for _, s := range w.Children {
+			node.Steps = append(node.Steps, stepNode{
+				Name:            s.Name,
+				PID:             s.PID,
+				StepID:          s.ID,
+				State:           string(s.State),
+				ExitCode:        s.ExitCode,
+				Started:         formatUnix(s.Started),
+				Finished:        formatUnix(s.Finished),
+				DurationSeconds: durationSeconds(s.Started, s.Finished, now),
+				Failed:          isFailedState(string(s.State)),
+				Error:           s.Error,
+			})
+		}
+
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+func failedSteps(workflows []workflowNode) []failedStepRef {
+	refs := []failedStepRef{}
+	for _, w := range workflows {
+		for _, s := range w.Steps {
+			if s.Failed {
+				refs = append(refs, failedStepRef{Workflow: w.Name, Step: s.Name, StepID: s.StepID, ExitCode: s.ExitCode})
+			}
+		}
+	}
+	return refs
+}
+
+// isFailedState reports whether a Woodpecker state means the work did not succeed;
+// killed (cancelled) and skipped are not failures
+func isFailedState(state string) bool {
+	return state == "failure" || state == "error"
+}
+
+func formatUnix(seconds int64) string {
+	if seconds <= 0 {
+		return ""
+	}
+	return time.Unix(seconds, 0).UTC().Format(time.RFC3339)
+}
+
+func durationSeconds(started, finished int64, now time.Time) int64 {
+	if started <= 0 {
+		return 0
+	}
+	if finished <= 0 {
+		return max(now.Unix()-started, 0)
+	}
+	return max(finished-started, 0)
+}
+
+// getStepIDArg returns step_id, or resolves step_name (and optionally workflow_name)
+// against the pipeline's workflow tree
+func (tm *ToolManager) getStepIDArg(ctx context.Context, repoID, pipelineNum int64, arguments map[string]interface{}) (int64, error) {
+	if _, ok := arguments["step_id"]; ok {
+		stepID, err := requireNumber(arguments, "step_id")
+		return int64(stepID), err
+	}
+
+	stepName := getString(arguments, "step_name", "")
+	if stepName == "" {
+		return 0, client.Validationf("either step_id or step_name is required; use get_pipeline_steps to list the steps")
+	}
+
+	pipeline, err := tm.client.GetPipeline(ctx, repoID, pipelineNum)
+	if err != nil {
+		return 0, err
+	}
+
+	step, err := findStep(pipeline.Workflows, stepName, getString(arguments, "workflow_name", ""))
+	if err != nil {
+		return 0, err
+	}
+	return step.ID, nil
+}
+
+// findStep finds the single step called stepName, limited to workflowName when set.
+// Names are matched exactly first and case-insensitively as a fallback.
+func findStep(workflows []*woodpecker.Workflow, stepName, workflowName string) (*woodpecker.Step, error) {
+	for _, equal := range []func(a, b string) bool{
+		func(a, b string) bool { return a == b },
+		strings.EqualFold,
+	} {
+		var matches []*woodpecker.Step
+		var qualified []string
+		for _, w := range workflows {
+			if workflowName != "" && !equal(w.Name, workflowName) {
+				continue
+			}
+			for _, s := range w.Children {
+				if equal(s.Name, stepName) {
+					matches = append(matches, s)
+					qualified = append(qualified, w.Name+"/"+s.Name)
+				}
+			}
+		}
+
+		switch len(matches) {
+		case 0:
+			continue
+		case 1:
+			return matches[0], nil
+		default:
+			return nil, client.Validationf("step name %q is ambiguous, it exists in several workflows: %s; set workflow_name or use step_id",
+				stepName, strings.Join(qualified, ", "))
+		}
+	}
+
+	target := fmt.Sprintf("step %q", stepName)
+	if workflowName != "" {
+		target += fmt.Sprintf(" in workflow %q", workflowName)
+	}
+	return nil, client.Validationf("%s not found; available steps: %s", target, strings.Join(availableSteps(workflows), ", "))
+}
+
+// availableSteps lists every step as workflow/step for error messages
+func availableSteps(workflows []*woodpecker.Workflow) []string {
+	var names []string
+	for _, w := range workflows {
+		for _, s := range w.Children {
+			names = append(names, w.Name+"/"+s.Name)
+		}
+	}
+	if len(names) == 0 {
+		return []string{"(none, the pipeline has no steps yet)"}
+	}
+	return names
+}
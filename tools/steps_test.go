@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+)
+
+func TestBuildStepTree(t *testing.T) {
+	now := time.Unix(1_700_000_100, 0)
+	workflows := []*woodpecker.Workflow{
+		{
+			ID: 10, PID: 1, Name: "build", State: "failure", Started: 1_700_000_000, Finished: 1_700_000_060,
+			Children: []*woodpecker.Step{
+				{ID: 11, PID: 2, Name: "clone", State: "success", Started: 1_700_000_000, Finished: 1_700_000_005},
+				{ID: 12, PID: 3, Name: "test", State: "failure", ExitCode: 2, Started: 1_700_000_005, Finished: 1_700_000_060},
+				{ID: 13, PID: 4, Name: "publish", State: "skipped"},
+			},
+		},
+		{
+			ID: 20, PID: 5, Name: "lint", State: "running", Started: 1_700_000_040,
+			Children: []*woodpecker.Step{
+				{ID: 21, PID: 6, Name: "golangci", State: "running", Started: 1_700_000_040},
+			},
+		},
+	}
+
+	tree := buildStepTree(workflows, now)
+	require.Len(t, tree, 2)
+
+	build := tree[0]
+	require.Equal(t, "build", build.Name)
+	require.True(t, build.Failed)
+	require.Equal(t, int64(60), build.DurationSeconds)
+	require.Equal(t, "2023-11-14T22:13:20Z", build.Started)
+	require.Len(t, build.Steps, 3)
+
+	test := build.Steps[1]
+	require.Equal(t, int64(12), test.StepID)
+	require.Equal(t, 2, test.ExitCode)
+	require.True(t, test.Failed)
+	require.Equal(t, int64(55), test.DurationSeconds)
+
+	skipped := build.Steps[2]
+	require.False(t, skipped.Failed)
+	require.Empty(t, skipped.Started)
+	require.Zero(t, skipped.DurationSeconds)
+
+	running := tree[1].Steps[0]
+	require.False(t, running.Failed)
+	require.Empty(t, running.Finished)
+	require.Equal(t, int64(60), running.DurationSeconds)
+
+	failed := failedSteps(tree)
+	require.Equal(t, []failedStepRef{{Workflow: "build", Step: "test", StepID: 12, ExitCode: 2}}, failed)
+}
+
+func TestFailedSteps_EmptyIsNotNil(t *testing.T) {
+	require.NotNil(t, failedSteps(nil))
+}
+
+func TestFindStep(t *testing.T) {
+	workflows := []*woodpecker.Workflow{
+		{Name: "build", Children: []*woodpecker.Step{{ID: 1, Name: "clone"}, {ID: 2, Name: "test"}}},
+		{Name: "lint", Children: []*woodpecker.Step{{ID: 3, Name: "clone"}, {ID: 4, Name: "Vet"}}},
+	}
+
+	step, err := findStep(workflows, "test", "")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), step.ID)
+
+	step, err = findStep(workflows, "clone", "lint")
+	require.NoError(t, err)
+	require.Equal(t, int64(3), step.ID)
+
+	step, err = findStep(workflows, "vet", "")
+	require.NoError(t, err)
+	require.Equal(t, int64(4), step.ID)
+
+	_, err = findStep(workflows, "clone", "")
+	require.ErrorIs(t, err, client.ErrValidation)
+	require.Contains(t, err.Error(), "ambiguous")
+	require.Contains(t, err.Error(), "build/clone, lint/clone")
+
+	_, err = findStep(workflows, "deploy", "")
+	require.ErrorIs(t, err, client.ErrValidation)
+	require.Contains(t, err.Error(), "available steps: build/clone, build/test, lint/clone, lint/Vet")
+
+	_, err = findStep(workflows, "test", "lint")
+	require.ErrorIs(t, err, client.ErrValidation)
+	require.Contains(t, err.Error(), `in workflow "lint"`)
+}
@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+)
+
+// newTriggerTestManager serves repo 7 with default branch develop and cron 3;
+// created pipelines are stored in created
+func newTriggerTestManager(t *testing.T, created *[]woodpecker.PipelineOptions) *ToolManager {
+	t.Helper()
+
+	return newFakeWoodpecker(t, map[string]http.HandlerFunc{
+		"/api/repos/7":          jsonRoute(`{"id": 7, "full_name": "org/app", "default_branch": "develop"}`),
+		"/api/repos/7/branches": jsonRoute(`["develop", "main"]`),
+		"POST /api/repos/7/pipelines": func(w http.ResponseWriter, r *http.Request) {
+			var opts woodpecker.PipelineOptions
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&opts))
+			*created = append(*created, opts)
+			_, _ = w.Write([]byte(`{"id": 90, "number": 12, "branch": "` + opts.Branch + `", "event": "manual", "status": "pending"}`))
+		},
+		"POST /api/repos/7/cron/3": jsonRoute(`{"id": 91, "number": 13, "branch": "develop", "event": "cron", "status": "pending"}`),
+	})
+}
+
+func TestTriggerPipeline_DefaultBranchAndVariables(t *testing.T) {
+	var created []woodpecker.PipelineOptions
+	tm := newTriggerTestManager(t, &created)
+
+	result := callTool(t, tm, "trigger_pipeline", map[string]interface{}{
+		"repo_id":   float64(7),
+		"variables": map[string]interface{}{"DEPLOY_ENV": "staging", "RETRIES": float64(3)},
+	})
+
+	require.False(t, result.IsError)
+	require.Len(t, created, 1)
+	require.Equal(t, "develop", created[0].Branch)
+	require.Equal(t, map[string]string{"DEPLOY_ENV": "staging", "RETRIES": "3"}, created[0].Variables)
+
+	body := resultJSON(t, result)
+	require.Equal(t, "develop", body["pipeline"].(map[string]interface{})["branch"])
+	require.Contains(t, body["web_url"], "/repos/7/pipeline/12")
+}
+
+func TestTriggerPipeline_RejectsUnknownBranch(t *testing.T) {
+	var created []woodpecker.PipelineOptions
+	tm := newTriggerTestManager(t, &created)
+
+	result := callTool(t, tm, "trigger_pipeline", map[string]interface{}{
+		"repo_id": float64(7),
+		"branch":  "nope",
+	})
+
+	require.True(t, result.IsError)
+	require.Contains(t, result.Content[0].(mcp.TextContent).Text, "does not exist")
+	require.Empty(t, created)
+}
+
+func TestGetVariables(t *testing.T) {
+	variables, err := getVariables(map[string]interface{}{})
+	require.NoError(t, err)
+	require.Nil(t, variables)
+
+	_, err = getVariables(map[string]interface{}{"variables": "A=1"})
+	require.ErrorIs(t, err, client.ErrValidation)
+
+	_, err = getVariables(map[string]interface{}{"variables": map[string]interface{}{"A": []interface{}{"x"}}})
+	require.ErrorIs(t, err, client.ErrValidation)
+}
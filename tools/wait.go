@@ -0,0 +1,181 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+
+	"github.com/denysvitali/woodpecker-ci-mcp/internal/client"
+)
+
+const (
+	defaultWaitTimeoutSeconds = 300
+	maxWaitTimeoutSeconds     = 1800
+)
+
+// Polling starts at waitPollInterval after a state change and backs off by half
+// again per unchanged poll, up to waitMaxPollInterval
+var (
+	waitPollInterval    = 2 * time.Second
+	waitMaxPollInterval = 30 * time.Second
+)
+
+// terminalPipelineStates are the states a pipeline does not leave on its own
+var terminalPipelineStates = map[string]bool{
+	"success":  true,
+	"failure":  true,
+	"killed":   true,
+	"error":    true,
+	"skipped":  true,
+	"declined": true,
+}
+
+// stateTransition is a change of the pipeline's status or of one of its steps' states
+type stateTransition struct {
+	Time   string `json:"time"`
+	Target string `json:"target"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to"`
+}
+
+func (tm *ToolManager) handleWaitForPipeline(ctx context.Context, arguments map[string]interface{}) (*mcp.CallToolResult, error) {
+	if cancelled := checkContextCancelled(ctx); cancelled != nil {
+		return cancelled, nil
+	}
+
+	repoID, err := getRepoID(ctx, tm.client, arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	timeout := time.Duration(clampInt(int(getNumber(arguments, "timeout_seconds", defaultWaitTimeoutSeconds)), 1, maxWaitTimeoutSeconds)) * time.Second
+
+	start := time.Now()
+	deadline := start.Add(timeout)
+
+	pipeline, err := tm.getPipelineArg(ctx, repoID, arguments)
+	if err != nil {
+		return tm.errorResult(err), nil
+	}
+
+	notifier := tm.newNotifier(ctx, "wait_for_pipeline")
+	seen := map[string]string{}
+	transitions := []stateTransition{}
+	interval := waitPollInterval
+	polls := 1
+	timedOut := false
+
+	for {
+		changes := pipelineTransitions(pipeline, seen, time.Now())
+		for _, change := range changes {
+			message := fmt.Sprintf("%s: %s", change.Target, change.To)
+			if change.From != "" {
+				message = fmt.Sprintf("%s: %s -> %s", change.Target, change.From, change.To)
+			}
+			notifier.notify(ctx, message, map[string]interface{}{
+				"pipeline_number": pipeline.Number,
+				"target":          change.Target,
+				"from":            change.From,
+				"to":              change.To,
+			})
+		}
+		transitions = append(transitions, changes...)
+
+		status := string(pipeline.Status)
+		if terminalPipelineStates[status] || status == "blocked" {
+			break
+		}
+
+		if len(changes) > 0 {
+			interval = waitPollInterval
+		} else {
+			interval = min(interval*3/2, waitMaxPollInterval)
+		}
+
+		wait := min(interval, time.Until(deadline))
+		if wait <= 0 {
+			timedOut = true
+			break
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return checkContextCancelled(ctx), nil
+		case <-timer.C:
+		}
+
+		next, err := tm.client.GetPipeline(ctx, repoID, pipeline.Number)
+		polls++
+		if err != nil {
+			if client.AsError(err).Retryable() {
+				tm.logger.WithError(err).Debug("Transient error while waiting for pipeline, backing off")
+				continue
+			}
+			return tm.errorResult(err), nil
+		}
+		pipeline = next
+	}
+
+	status := string(pipeline.Status)
+	response := map[string]interface{}{
+		"repo_id":         repoID,
+		"pipeline_number": pipeline.Number,
+		"status":          status,
+		"finished":        terminalPipelineStates[status],
+		"timed_out":       timedOut,
+		"elapsed_seconds": int64(time.Since(start).Seconds()),
+		"polls":           polls,
+		"transitions":     transitions,
+		"failed_steps":    failedSteps(buildStepTree(pipeline.Workflows, time.Now())),
+		"forge_url":       pipeline.ForgeURL,
+	}
+
+	switch {
+	case timedOut:
+		response["hint"] = "The pipeline is still running; call wait_for_pipeline again to keep waiting"
+	case status == "blocked":
+		response["hint"] = "The pipeline is waiting for approval; use approve_pipeline to let it run"
+	}
+
+	return tm.jsonResult(response)
+}
+
+// pipelineTransitions compares p with the states in seen, records the new ones and
+// returns what changed. Steps present at the first observation are taken as the
+// starting point rather than reported as changes.
+func pipelineTransitions(p *woodpecker.Pipeline, seen map[string]string, now time.Time) []stateTransition {
+	first := len(seen) == 0
+	var changes []stateTransition
+
+	observe := func(target, state string, report bool) {
+		previous, ok := seen[target]
+		if ok && previous == state {
+			return
+		}
+		seen[target] = state
+		if report {
+			changes = append(changes, stateTransition{
+				Time:   now.UTC().Format(time.RFC3339),
+				Target: target,
+				From:   previous,
+				To:     state,
+			})
+		}
+	}
+
+	observe("pipeline", string(p.Status), true)
+	for _, w := range p.Workflows {
+		for _, s := range w.Children {
+			target := w.Name + "/" + s.Name
+			_, known := seen[target]
+			observe(target, string(s.State), known || !first)
+		}
+	}
+
+	return changes
+}
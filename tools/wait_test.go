@@ -0,0 +1,115 @@
+package tools
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+	"go.woodpecker-ci.org/woodpecker/v3/woodpecker-go/woodpecker"
+)
+
+func fastPolling(t *testing.T) {
+	t.Helper()
+	interval, maxInterval := waitPollInterval, waitMaxPollInterval
+	waitPollInterval, waitMaxPollInterval = time.Millisecond, 5*time.Millisecond
+	t.Cleanup(func() { waitPollInterval, waitMaxPollInterval = interval, maxInterval })
+}
+
+func TestWaitForPipeline_ReturnsFailedStepsWhenFinished(t *testing.T) {
+	fastPolling(t)
+
+	responses := []string{
+		`{"number": 4, "status": "pending", "workflows": [{"name": "build", "children": [{"id": 1, "name": "test", "state": "pending"}]}]}`,
+		`{"number": 4, "status": "running", "workflows": [{"name": "build", "children": [{"id": 1, "name": "test", "state": "running"}]}]}`,
+		`{"number": 4, "status": "running", "workflows": [{"name": "build", "children": [{"id": 1, "name": "test", "state": "running"}]}]}`,
+		`{"number": 4, "status": "failure", "workflows": [{"name": "build", "state": "failure", "children": [{"id": 1, "name": "test", "state": "failure", "exit_code": 2}]}]}`,
+	}
+	var polls atomic.Int32
+	srv := newMCPTestServer(t, map[string]http.HandlerFunc{
+		"/api/repos/1/pipelines/4": func(w http.ResponseWriter, r *http.Request) {
+			n := int(polls.Add(1)) - 1
+			_, _ = w.Write([]byte(responses[min(n, len(responses)-1)]))
+		},
+	})
+
+	body, notifications := callWithSession(t, srv, newTestSession(mcp.LoggingLevelError), `{
+		"name": "wait_for_pipeline",
+		"arguments": {"repo_id": 1, "pipeline_number": 4},
+		"_meta": {"progressToken": 7}
+	}`)
+
+	require.Equal(t, "failure", body["status"])
+	require.Equal(t, true, body["finished"])
+	require.Equal(t, false, body["timed_out"])
+	require.EqualValues(t, 4, body["polls"])
+
+	failed := body["failed_steps"].([]interface{})
+	require.Len(t, failed, 1)
+	require.Equal(t, "test", failed[0].(map[string]interface{})["step"])
+
+	var messages []string
+	for _, n := range notifications {
+		messages = append(messages, n.Params.AdditionalFields["message"].(string))
+	}
+	require.Equal(t, []string{
+		"pipeline: pending",
+		"pipeline: pending -> running",
+		"build/test: pending -> running",
+		"pipeline: running -> failure",
+		"build/test: running -> failure",
+	}, messages)
+	require.Len(t, body["transitions"], 5)
+}
+
+func TestWaitForPipeline_StopsWhenBlocked(t *testing.T) {
+	fastPolling(t)
+
+	srv := newMCPTestServer(t, map[string]http.HandlerFunc{
+		"/api/repos/1/pipelines/4": jsonRoute(`{"number": 4, "status": "blocked"}`),
+	})
+
+	body, _ := callWithSession(t, srv, newTestSession(mcp.LoggingLevelError), `{
+		"name": "wait_for_pipeline",
+		"arguments": {"repo_id": 1, "pipeline_number": 4}
+	}`)
+
+	require.Equal(t, "blocked", body["status"])
+	require.Equal(t, false, body["finished"])
+	require.Contains(t, body["hint"], "approve_pipeline")
+}
+
+func TestWaitForPipeline_TimesOut(t *testing.T) {
+	fastPolling(t)
+
+	srv := newMCPTestServer(t, map[string]http.HandlerFunc{
+		"/api/repos/1/pipelines/4": jsonRoute(`{"number": 4, "status": "running"}`),
+	})
+
+	body, _ := callWithSession(t, srv, newTestSession(mcp.LoggingLevelError), `{
+		"name": "wait_for_pipeline",
+		"arguments": {"repo_id": 1, "pipeline_number": 4, "timeout_seconds": 1}
+	}`)
+
+	require.Equal(t, "running", body["status"])
+	require.Equal(t, true, body["timed_out"])
+	require.Greater(t, body["polls"], float64(2))
+}
+
+func TestPipelineTransitions_SeedsStepsOnFirstObservation(t *testing.T) {
+	seen := map[string]string{}
+	pipeline := &woodpecker.Pipeline{
+		Status: "running",
+		Workflows: []*woodpecker.Workflow{
+			{Name: "build", Children: []*woodpecker.Step{{Name: "test", State: "running"}}},
+		},
+	}
+
+	changes := pipelineTransitions(pipeline, seen, time.Now())
+	require.Len(t, changes, 1)
+	require.Equal(t, "pipeline", changes[0].Target)
+
+	require.Empty(t, pipelineTransitions(pipeline, seen, time.Now()))
+}